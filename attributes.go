@@ -17,6 +17,7 @@ const (
 	stateKeyRead
 	stateLookingForValue
 	stateReadingValue
+	stateReadingGroup
 	stateLookingForDelimiter
 )
 
@@ -28,11 +29,12 @@ func KeyValue(raw string) (map[string]string, error) {
 	value := ""
 	state := stateLookingForKey
 	escape := rune(0)
+	depth := 0 // nesting of braces in a value wrapped in {}
 
 	for {
 		char, _, err := read.ReadRune()
 		if err == io.EOF {
-			if state == stateReadingValue {
+			if state == stateReadingValue || state == stateReadingGroup {
 				attr[key] = value
 			}
 
@@ -84,6 +86,10 @@ func KeyValue(raw string) (map[string]string, error) {
 				value = ""
 				state = stateReadingValue
 				escape = char
+			} else if char == '{' {
+				value = ""
+				state = stateReadingGroup
+				depth = 1
 			} else if char != ' ' {
 				value = string(char)
 				state = stateReadingValue
@@ -134,6 +140,22 @@ func KeyValue(raw string) (map[string]string, error) {
 
 			value += string(char)
 
+		case stateReadingGroup:
+			switch char {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+
+			if depth == 0 {
+				attr[key] = value
+				state = stateLookingForDelimiter
+				continue
+			}
+
+			value += string(char)
+
 		case stateLookingForDelimiter:
 			if char == ',' {
 				state = stateLookingForKey
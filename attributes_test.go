@@ -90,6 +90,11 @@ func TestKeyValue(t *testing.T) {
 			input:  "type=note, title=\"Привіт 👋\"",
 			output: map[string]string{"type": "note", "title": "Привіт 👋"},
 		},
+		{
+			name:   "values wrapped in braces",
+			input:  "caption={Sum of {two} numbers, fast}, label=lst:sum",
+			output: map[string]string{"caption": "Sum of {two} numbers, fast", "label": "lst:sum"},
+		},
 		{
 			name:   "ignore invalid parts",
 			input:  "type=note @ 2, fo, from=hello@eolymp.com",
@@ -0,0 +1,128 @@
+package latex_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/eolymp/go-latex"
+)
+
+// benchmark documents, tables are the same as in cf33 and cf35 parser tests
+var benchmarks = []struct {
+	name  string
+	input string
+}{
+	{name: "statement", input: strings.Repeat("Given an array of $n$ integers $a_1, a_2, \\ldots, a_n$ (\\textit{$1 \\le n \\le 10^5$}), find the \\textbf{maximum} sum of a subarray~--- a ``contiguous'' part of the array.\n\n\\section{Input}\nThe first line contains $n$, the second line contains $n$ integers.\n\n\\begin{itemize}\n\\item first\n\\item second\n\\end{itemize}\n\n", 50)},
+	{name: "cf33", input: "Scoring table example:\n\\begin{center}\n  \\begin{tabular}{ | c | c | c | c | } \\hline\n    \\bf{Group} &\n    \\bf{Add. constraints} &\n    \\bf{Points} &\n    \\bf{Req. groups} \\\\ \\hline\n    $1$ & $b = a + 1$ & $30$ & --- \\\\ \\hline\n    $2$ & $n \\le 1\\,000$ & $10$ & examples \\\\ \\hline\n    $3$ & $n \\le 10^7$ & $20$ & $2$ \\\\ \\hline\n    $4$ & --- & $40$ & $1$, $3$ \\\\ \\hline\n  \\end{tabular}\n\\end{center}"},
+	{name: "cf35", input: "Advanced scoring table example (colspan and rowspan):\n\\begin{tabular}{|c|c|c|c|c|}\n   \\hline\n    \\multirow{2}{*}{\\bf{Group}} &\n    \\multicolumn{2}{c|}{\\bf{Add. constraints}} &\n    \\multirow{2}{*}{\\bf{Points}} &\n    \\multirow{2}{*}{\\bf{Req. groups}} \\\\ \\cline{2-3}\n      & $n$ & $a_i$ & & \\\\ \\hline\n    $1$ & $n \\le 10$ & --- & $12$ & --- \\\\ \\hline\n    $2$ & $n \\le 500$ & $a_i \\le 100$ & $19$ & --- \\\\ \\hline\\end{tabular}"},
+	{name: "table", input: "\\begin{tabular}{|l|c|r|}\n\\hline\n" + strings.Repeat("\\bf{Group} & $n \\le 1\\,000$ & \\multicolumn{1}{c|}{text} \\\\ \\hline\n", 500) + "\\end{tabular}"},
+	{name: "code", input: strings.Repeat("Solution:\n\\begin{lstlisting}[language=C++]\n#include <iostream>\nint main() {\n    int a, b;\n    std::cin >> a >> b;\n    std::cout << a + b << std::endl;\n}\n\\end{lstlisting}\nUse \\verb|std::cin| or \\texttt{scanf}.\n\n", 50)},
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(bm.input)))
+
+			for i := 0; i < b.N; i++ {
+				if _, err := latex.Parse(strings.NewReader(bm.input)); err != nil {
+					b.Fatalf("Unable to parse document: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			doc, err := latex.Parse(strings.NewReader(bm.input))
+			if err != nil {
+				b.Fatalf("Unable to parse document: %v", err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if err := latex.Render(io.Discard, doc); err != nil {
+					b.Fatalf("Unable to render document: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRenderHTML(b *testing.B) {
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			doc, err := latex.Parse(strings.NewReader(bm.input))
+			if err != nil {
+				b.Fatalf("Unable to parse document: %v", err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if err := latex.RenderHTML(io.Discard, doc); err != nil {
+					b.Fatalf("Unable to render document: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTokenizer(b *testing.B) {
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(bm.input)))
+
+			for i := 0; i < b.N; i++ {
+				tokens := latex.NewTokenizer(bytes.NewReader([]byte(bm.input)))
+				for {
+					if _, err := tokens.Token(); err != nil {
+						if err != io.EOF {
+							b.Fatalf("Unable to read token: %v", err)
+						}
+
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestAllocations guards against allocation regressions in the hot loops, limits have some headroom over the current
+// numbers (see benchmarks above), so they fail only on significant changes
+func TestAllocations(t *testing.T) {
+	input := benchmarks[1].input
+
+	doc, err := latex.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	tt := []struct {
+		name  string
+		limit float64
+		run   func()
+	}{
+		{name: "parse", limit: 1000, run: func() { _, _ = latex.Parse(strings.NewReader(input)) }},
+		{name: "render", limit: 500, run: func() { _ = latex.Render(io.Discard, doc) }},
+		{name: "render html", limit: 600, run: func() { _ = latex.RenderHTML(io.Discard, doc) }},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if allocs := testing.AllocsPerRun(10, tc.run); allocs > tc.limit {
+				t.Errorf("Too many allocations: %v, limit is %v", allocs, tc.limit)
+			}
+		})
+	}
+}
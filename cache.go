@@ -0,0 +1,42 @@
+package latex
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+)
+
+// Cache stores parsed documents by hash of their source, see ParseCached
+type Cache interface {
+	Get(key string) (*Node, bool)
+	Set(key string, node *Node)
+}
+
+// ParseCached parses document like Parse, but looks it up in the cache first using SHA-256 hash of the input as a key.
+// Parsed documents are stored in the cache, failed ones are not. Both stored and returned trees are copies, so
+// modifying the returned document does not affect the cache.
+//
+// Key does not include parser options, use separate caches for documents parsed with different options.
+func ParseCached(r io.Reader, cache Cache, opts ...ParserOption) (*Node, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(data)
+	key := hex.EncodeToString(hash[:])
+
+	if node, ok := cache.Get(key); ok {
+		return node.Clone(), nil
+	}
+
+	node, err := NewParser(bytes.NewReader(data), opts...).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Set(key, node.Clone())
+
+	return node, nil
+}
@@ -0,0 +1,58 @@
+package latex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/eolymp/go-latex"
+)
+
+type mapCache map[string]*latex.Node
+
+func (c mapCache) Get(key string) (*latex.Node, bool) {
+	node, ok := c[key]
+	return node, ok
+}
+
+func (c mapCache) Set(key string, node *latex.Node) {
+	c[key] = node
+}
+
+func TestParseCached(t *testing.T) {
+	input := "Hello, \\textbf{world}!\n\n\\begin{tabular}{ll}\na & b\n\\end{tabular}"
+	cache := mapCache{}
+
+	first, err := latex.ParseCached(strings.NewReader(input), cache)
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	if len(cache) != 1 {
+		t.Fatalf("Parsed document is expected to be cached, cache has %d entries", len(cache))
+	}
+
+	// modification of the returned tree must not affect the cache
+	first.Children[0].Children[0].Data = "Bye, "
+
+	second, err := latex.ParseCached(strings.NewReader(input), cache)
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	want, err := latex.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	if !cmp.Equal(want, second) {
+		t.Errorf("Cached document does not match:\n%s", cmp.Diff(want, second))
+	}
+
+	for _, cached := range cache {
+		if cached == second || cached.Children[0] == second.Children[0] {
+			t.Errorf("Cached document is expected to be a copy")
+		}
+	}
+}
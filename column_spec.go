@@ -1,29 +1,171 @@
 package latex
 
+import (
+	"strconv"
+	"strings"
+)
+
 type ColumnSpec struct {
 	BorderLeft  bool   // column should have left border
 	BorderRight bool   // column should have right border
 	Align       string // column alignment: c, l or r
+	Stretch     bool   // column stretches to fill the table width (X column in tabularx)
+	Width       string // width of paragraph column (p, m and b columns)
 }
 
 // ColumnSpecs parses column spec in tabular environment
 // todo: add support for repeated syntax *{x}{...}
-// todo: if not support, at least correctly handle @{} and !{}
 func ColumnSpecs(raw string) (spec []ColumnSpec) {
 	raw = whitespaces.ReplaceAllString(raw, "") // remove all spaces since they don't have any meaning
-	for pos, char := range raw {
-		if char == '|' {
+
+	border := false // there is a border before the next column
+	after := false  // position is right after a column, so border belongs to it
+	align := ""     // alignment set by >{...} modifier for the next column
+
+	add := func(column ColumnSpec) {
+		column.BorderLeft = border
+		spec = append(spec, column)
+		border = false
+		after = true
+		align = ""
+	}
+
+	for pos := 0; pos < len(raw); pos++ {
+		switch char := raw[pos]; char {
+		case '|':
+			if after {
+				spec[len(spec)-1].BorderRight = true
+			}
+
+			border = true
+		case 'c', 'l', 'r':
+			add(ColumnSpec{Align: string(char)})
+		case 'X':
+			add(ColumnSpec{Align: "l", Stretch: true})
+		case 'p', 'm', 'b':
+			width, next := group(raw, pos+1)
+			pos = next - 1
+
+			column := ColumnSpec{Align: align, Width: width}
+			if column.Align == "" {
+				column.Align = "l"
+			}
+
+			add(column)
+		case '>':
+			// modifier applied to the next column, alignment declarations are used for paragraph columns
+			content, next := group(raw, pos+1)
+			pos = next - 1
+			after = false
+
+			switch {
+			case strings.Contains(content, "\\centering"):
+				align = "c"
+			case strings.Contains(content, "\\raggedleft"):
+				align = "r"
+			case strings.Contains(content, "\\raggedright"):
+				align = "l"
+			}
+		case '<':
+			// modifier applied to the previous column
+			_, next := group(raw, pos+1)
+			pos = next - 1
+		case '@', '!':
+			// separators between columns, they don't define columns
+			_, next := group(raw, pos+1)
+			pos = next - 1
+			after = false
+		}
+	}
+
+	return
+}
+
+// group reads group wrapped in {} starting at pos, it returns content of the group and position after the group.
+// If there is no group at pos, it returns empty string and pos.
+func group(raw string, pos int) (string, int) {
+	if pos >= len(raw) || raw[pos] != '{' {
+		return "", pos
+	}
+
+	depth := 0
+	for i := pos; i < len(raw); i++ {
+		switch raw[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return raw[pos+1 : i], i + 1
+			}
+		}
+	}
+
+	return raw[pos+1:], len(raw)
+}
+
+// columnType is a custom column type defined by \\newcolumntype
+type columnType struct {
+	args       int    // number of arguments column type takes
+	definition string // column spec column type expands to, arguments are referenced as #1, #2 etc.
+}
+
+// maxColumnTypeExpansions limits nesting of custom column types, so recursive definitions do not hang the parser
+const maxColumnTypeExpansions = 10
+
+// expandColumnTypes replaces custom column types in colspec with their definitions
+func expandColumnTypes(raw string, types map[string]columnType) string {
+	if len(types) == 0 {
+		return raw
+	}
+
+	for i := 0; i < maxColumnTypeExpansions; i++ {
+		expanded, ok := expandColumnTypesOnce(raw, types)
+		if !ok {
+			break
+		}
+
+		raw = expanded
+	}
+
+	return raw
+}
+
+// expandColumnTypesOnce replaces custom column types found outside of {} groups, it returns false if there was nothing
+// to replace
+func expandColumnTypesOnce(raw string, types map[string]columnType) (string, bool) {
+	out := strings.Builder{}
+	expanded := false
+
+	for pos := 0; pos < len(raw); pos++ {
+		if raw[pos] == '{' {
+			_, next := group(raw, pos)
+			out.WriteString(raw[pos:next])
+			pos = next - 1
+			continue
+		}
+
+		t, ok := types[raw[pos:pos+1]]
+		if !ok {
+			out.WriteByte(raw[pos])
 			continue
 		}
 
-		if char == 'c' || char == 'l' || char == 'r' {
-			spec = append(spec, ColumnSpec{
-				BorderLeft:  pos > 0 && raw[pos-1] == '|',
-				BorderRight: pos < len(raw)-1 && raw[pos+1] == '|',
-				Align:       string([]rune{char}),
-			})
+		definition := t.definition
+		for arg := 1; arg <= t.args; arg++ {
+			next := pos + 1
+			for next < len(raw) && isWhitespace(rune(raw[next])) {
+				next++
+			}
+
+			value, end := group(raw, next)
+			definition = strings.ReplaceAll(definition, "#"+strconv.Itoa(arg), value)
+			pos = end - 1
 		}
+
+		out.WriteString(definition)
+		expanded = true
 	}
 
-	return
+	return out.String(), expanded
 }
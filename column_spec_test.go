@@ -0,0 +1,53 @@
+package latex_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestColumnSpecs(t *testing.T) {
+	tt := []struct {
+		name    string
+		colspec string
+		spec    []latex.ColumnSpec
+	}{
+		{
+			name:    "aligned columns with borders",
+			colspec: "|l|c r|",
+			spec: []latex.ColumnSpec{
+				{BorderLeft: true, BorderRight: true, Align: "l"},
+				{BorderLeft: true, Align: "c"},
+				{BorderRight: true, Align: "r"},
+			},
+		},
+		{
+			name:    "stretchy column",
+			colspec: "lX|",
+			spec: []latex.ColumnSpec{
+				{Align: "l"},
+				{BorderRight: true, Align: "l", Stretch: true},
+			},
+		},
+		{
+			name:    "paragraph columns",
+			colspec: "|>{\\centering\\arraybackslash}p{2cm}|m{0.5\\textwidth}@{ -- }b{1in}",
+			spec: []latex.ColumnSpec{
+				{BorderLeft: true, BorderRight: true, Align: "c", Width: "2cm"},
+				{BorderLeft: true, Align: "l", Width: "0.5\\textwidth"},
+				{Align: "l", Width: "1in"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := latex.ColumnSpecs(tc.colspec)
+			if !cmp.Equal(tc.spec, got) {
+				t.Errorf("Column specs do not match:\n%s", cmp.Diff(tc.spec, got))
+			}
+		})
+	}
+}
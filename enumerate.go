@@ -0,0 +1,154 @@
+package latex
+
+import (
+	"strconv"
+	"strings"
+)
+
+var counterStyles = []string{"\\arabic*", "\\alph*", "\\Alph*", "\\roman*", "\\Roman*"}
+
+// enumerateCounters are counters of nested enumerate environments, deeper levels use the last one
+var enumerateCounters = []string{"enumi", "enumii", "enumiii", "enumiv"}
+
+// enumerateCounter returns name of the counter used by enumerate environment at given depth (starting from 0)
+func enumerateCounter(depth int) string {
+	return enumerateCounters[min(depth, len(enumerateCounters)-1)]
+}
+
+// enumerateStart returns number of the first item of enumerate environment with given options: enumitem start=N
+// sets it explicitly and resume continues numbering from the previous list (current is the last number of it)
+func enumerateStart(options string, current int) int {
+	if kv, err := KeyValue(options); err == nil && kv["start"] != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(kv["start"])); err == nil {
+			return n
+		}
+	}
+
+	// resume is a key without value, so it's not returned by KeyValue
+	for _, key := range strings.Split(options, ",") {
+		if key = strings.TrimSpace(key); key == "resume" || key == "resume*" {
+			return current + 1
+		}
+	}
+
+	return 1
+}
+
+// EnumerateLabels returns labels of items in enumerate environment (1., a), (i) etc.) according to the label
+// style set in environment options. Both enumitem (label=(\\alph*)) and enumerate package ((a)) notations
+// are supported.
+func EnumerateLabels(node *Node) (labels []string) {
+	style, prefix, suffix := enumerateStyle(node.Parameters["options"])
+
+	counter := 0
+	if v, err := strconv.Atoi(node.Parameters["start"]); err == nil {
+		counter = v - 1
+	}
+
+	for _, child := range node.Children {
+		if child.Kind != ElementKind || child.Data != "\\item" {
+			continue
+		}
+
+		counter++
+		if v, err := strconv.Atoi(child.Parameters["value"]); err == nil {
+			counter = v
+		}
+
+		labels = append(labels, prefix+formatCounter(counter, style)+suffix)
+	}
+
+	return
+}
+
+// enumerateStyle parses enumerate options and returns counter style (\\arabic*, \\alph*, \\Alph*, \\roman* or
+// \\Roman*) along with the text surrounding the counter
+func enumerateStyle(options string) (style, prefix, suffix string) {
+	if strings.Contains(options, "=") {
+		kv, err := KeyValue(options)
+		if err != nil || kv["label"] == "" {
+			return "\\arabic*", "", "."
+		}
+
+		label := kv["label"]
+		for _, style := range counterStyles {
+			if index := strings.Index(label, style); index >= 0 {
+				return style, label[:index], label[index+len(style):]
+			}
+		}
+
+		return "\\arabic*", "", "."
+	}
+
+	// options like [noitemsep] are enumitem keys rather than a label template
+	if len(options) > 1 && strings.TrimLeft(options, "abcdefghijklmnopqrstuvwxyz,* ") == "" {
+		return "\\arabic*", "", "."
+	}
+
+	for index, r := range options {
+		switch r {
+		case '1':
+			style = "\\arabic*"
+		case 'a':
+			style = "\\alph*"
+		case 'A':
+			style = "\\Alph*"
+		case 'i':
+			style = "\\roman*"
+		case 'I':
+			style = "\\Roman*"
+		default:
+			continue
+		}
+
+		return style, options[:index], options[index+1:]
+	}
+
+	return "\\arabic*", "", "."
+}
+
+// formatCounter formats counter value in given style
+func formatCounter(n int, style string) string {
+	switch style {
+	case "\\alph*":
+		return alph(n)
+	case "\\Alph*":
+		return strings.ToUpper(alph(n))
+	case "\\roman*":
+		return roman(n)
+	case "\\Roman*":
+		return strings.ToUpper(roman(n))
+	default:
+		return strconv.Itoa(n)
+	}
+}
+
+// alph formats number as a sequence of latin letters: a, b, ..., z, aa, ab, ...
+func alph(n int) (out string) {
+	for n > 0 {
+		n--
+		out = string(rune('a'+n%26)) + out
+		n /= 26
+	}
+
+	return
+}
+
+// roman formats number using lowercase roman numerals
+func roman(n int) (out string) {
+	if n <= 0 {
+		return strconv.Itoa(n)
+	}
+
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	numerals := []string{"m", "cm", "d", "cd", "c", "xc", "l", "xl", "x", "ix", "v", "iv", "i"}
+
+	for index, value := range values {
+		for n >= value {
+			out += numerals[index]
+			n -= value
+		}
+	}
+
+	return
+}
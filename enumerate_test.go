@@ -0,0 +1,96 @@
+package latex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestEnumerateLabels(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		output []string
+	}{
+		{
+			name:   "default",
+			input:  "\\begin{enumerate}\\item a\\item b\\end{enumerate}",
+			output: []string{"1.", "2."},
+		},
+		{
+			name:   "enumitem alph",
+			input:  "\\begin{enumerate}[label=\\alph*)]\\item a\\item b\\end{enumerate}",
+			output: []string{"a)", "b)"},
+		},
+		{
+			name:   "enumitem Roman",
+			input:  "\\begin{enumerate}[label=\\Roman*.]\\item a\\item b\\item c\\item d\\end{enumerate}",
+			output: []string{"I.", "II.", "III.", "IV."},
+		},
+		{
+			name:   "enumerate roman",
+			input:  "\\begin{enumerate}[(i)]\\item a\\item b\\item c\\end{enumerate}",
+			output: []string{"(i)", "(ii)", "(iii)"},
+		},
+		{
+			name:   "enumerate Alph",
+			input:  "\\begin{enumerate}[A.]\\item a\\item b\\end{enumerate}",
+			output: []string{"A.", "B."},
+		},
+		{
+			name:   "options without label",
+			input:  "\\begin{enumerate}[noitemsep,start=1]\\item a\\end{enumerate}",
+			output: []string{"1."},
+		},
+		{
+			name:   "enumitem start",
+			input:  "\\begin{enumerate}[start=5,label=\\alph*)]\\item a\\item b\\end{enumerate}",
+			output: []string{"e)", "f)"},
+		},
+		{
+			name:   "enumitem resume",
+			input:  "\\begin{enumerate}\\item a\\item b\\end{enumerate}\n\n\\begin{enumerate}[resume]\\item c\\item d\\end{enumerate}",
+			output: []string{"3.", "4."},
+		},
+		{
+			name:   "list after resumed list starts over",
+			input:  "\\begin{enumerate}\\item a\\end{enumerate}\n\n\\begin{enumerate}[resume]\\item b\\end{enumerate}\n\n\\begin{enumerate}\\item c\\end{enumerate}",
+			output: []string{"1."},
+		},
+		{
+			name:   "setcounter",
+			input:  "\\begin{enumerate}\\setcounter{enumi}{2}\\item a\\item b\\setcounter{enumi}{9}\\item c\\end{enumerate}",
+			output: []string{"3.", "4.", "10."},
+		},
+		{
+			name:   "setcounter of nested list",
+			input:  "\\begin{enumerate}\\item a\\begin{enumerate}\\setcounter{enumii}{3}\\item b\\end{enumerate}\\item c\\end{enumerate}",
+			output: []string{"1.", "2."},
+		},
+		{
+			name:   "keys without values",
+			input:  "\\begin{enumerate}[noitemsep]\\item a\\end{enumerate}",
+			output: []string{"1."},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := latex.Parse(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			// the last list is checked, so previous ones can set up counters
+			got := latex.EnumerateLabels(doc.Children[len(doc.Children)-1])
+			want := tc.output
+
+			if !cmp.Equal(want, got) {
+				t.Errorf("Labels do not match:\n%s\n", cmp.Diff(want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package latex
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// formatContext is number of lines printed by FormatError before and after the line with error
+const formatContext = 2
+
+// ParseError is an error returned by parser, it carries position in the input where error was discovered. Error
+// message is the same as message of the original error, position can be added with FormatError.
+type ParseError struct {
+	Offset int64 // offset in bytes
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Error allows to use diagnostic as an error, for example with FormatError
+func (d Diagnostic) Error() string {
+	return d.Message
+}
+
+// FormatError formats error with position (ParseError or Diagnostic) for humans: message is followed by the offending
+// line of the source with a caret under the column where error was discovered, and a few surrounding lines:
+//
+//	2:5: unknown command \foo
+//	1 | First line
+//	2 | Hey \foo{bar}
+//	  |     ^
+//	3 | Last line
+//
+// Errors without position are formatted as is.
+func FormatError(src string, err error) string {
+	var offset int64
+	var message string
+
+	var perr *ParseError
+	var diag Diagnostic
+
+	switch {
+	case errors.As(err, &perr):
+		offset, message = perr.Offset, perr.Err.Error()
+	case errors.As(err, &diag):
+		offset, message = diag.Offset, diag.Message
+	default:
+		return err.Error()
+	}
+
+	offset = min(max(offset, 0), int64(len(src)))
+
+	lines := strings.Split(src, "\n")
+	line := strings.Count(src[:offset], "\n")
+	prefix := src[strings.LastIndex(src[:offset], "\n")+1 : offset]
+
+	first, last := max(line-formatContext, 0), min(line+formatContext, len(lines)-1)
+	width := len(strconv.Itoa(last + 1))
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%d:%d: %s\n", line+1, utf8.RuneCountInString(prefix)+1, message)
+
+	for i := first; i <= last; i++ {
+		fmt.Fprintf(b, "%*d | %s\n", width, i+1, lines[i])
+
+		if i == line {
+			// keep tabs, so caret is aligned with the column regardless of tab width
+			caret := strings.Map(func(r rune) rune {
+				if r == '\t' {
+					return r
+				}
+
+				return ' '
+			}, prefix)
+
+			fmt.Fprintf(b, "%*s | %s^\n", width, "", caret)
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,84 @@
+package latex_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestFormatError(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{
+			name:   "first line",
+			input:  "Hey \\foo{bar}\nSecond line",
+			output: "1:9: unknown command \\foo\n1 | Hey \\foo{bar}\n  |         ^\n2 | Second line",
+		},
+		{
+			name:   "context lines",
+			input:  "One\nTwo\nThree\nFour \\foo.\nFive\nSix\nSeven",
+			output: "4:10: unknown command \\foo\n2 | Two\n3 | Three\n4 | Four \\foo.\n  |          ^\n5 | Five\n6 | Six",
+		},
+		{
+			name:   "tabs",
+			input:  "\t\\foo",
+			output: "1:6: unknown command \\foo\n1 | \t\\foo\n  | \t    ^",
+		},
+		{
+			name:   "wide line numbers",
+			input:  strings.Repeat("\n", 9) + "\\foo.\n",
+			output: "10:5: unknown command \\foo\n 8 | \n 9 | \n10 | \\foo.\n   |     ^\n11 | ",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := latex.Strict(strings.NewReader(tc.input))
+			if err == nil {
+				t.Fatal("Parse must fail")
+			}
+
+			var perr *latex.ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Error must carry position, got %T", err)
+			}
+
+			// position is not a part of the message
+			if err.Error() != perr.Err.Error() {
+				t.Errorf("Error message must be the original message %q, got %q", perr.Err.Error(), err.Error())
+			}
+
+			if got := latex.FormatError(tc.input, err); got != tc.output {
+				t.Errorf("Formatted error does not match:\nwant: %q\n got: %q", tc.output, got)
+			}
+		})
+	}
+}
+
+func TestFormatError_Diagnostic(t *testing.T) {
+	input := "Hey\n\\foo{bar}"
+
+	parser := latex.NewParser(strings.NewReader(input))
+	if _, err := parser.Parse(); err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	diagnostics := parser.Diagnostics()
+	if len(diagnostics) == 0 {
+		t.Fatal("Parser must report a diagnostic")
+	}
+
+	want := "2:5: unknown command \\foo\n1 | Hey\n2 | \\foo{bar}\n  |     ^"
+	if got := latex.FormatError(input, diagnostics[0]); got != want {
+		t.Errorf("Formatted error does not match:\nwant: %q\n got: %q", want, got)
+	}
+
+	if got := latex.FormatError(input, errors.New("no position")); got != "no position" {
+		t.Errorf("Error without position must be formatted as is, got %q", got)
+	}
+}
@@ -0,0 +1,27 @@
+package latex
+
+// Example is a sample test of a competitive programming problem
+type Example struct {
+	Input  string
+	Output string
+	Name   string // optional name of file-based example
+	File   bool   // Input and Output are references to files (defined by \\exmpfile) rather than content
+}
+
+// Examples returns sample tests defined by \\exmp and \\exmpfile commands in order of appearance
+func Examples(doc *Node) (examples []Example) {
+	walk(doc, func(node *Node) {
+		if node.Kind != ElementKind {
+			return
+		}
+
+		switch node.Data {
+		case "\\exmp":
+			examples = append(examples, Example{Input: node.Parameters["input"], Output: node.Parameters["output"]})
+		case "\\exmpfile":
+			examples = append(examples, Example{Input: node.Parameters["input"], Output: node.Parameters["output"], Name: node.Parameters["name"], File: true})
+		}
+	})
+
+	return
+}
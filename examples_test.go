@@ -0,0 +1,64 @@
+package latex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestExamples(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		output []latex.Example
+	}{
+		{
+			name:  "two examples",
+			input: "\\begin{problem}{Sum}{standard input}{standard output}{1 second}{256 megabytes}\nFind a+b.\n\\Examples\n\\exmp{1 2\n}{3\n}\\exmp{5 7\n}{12\n}\n\\end{problem}",
+			output: []latex.Example{
+				{Input: "1 2\n", Output: "3\n"},
+				{Input: "5 7\n", Output: "12\n"},
+			},
+		},
+		{
+			name:  "file examples",
+			input: "\\Example\n\\exmpfile{example.01}{example.01.a}{first}",
+			output: []latex.Example{
+				{Input: "example.01", Output: "example.01.a", Name: "first", File: true},
+			},
+		},
+		{
+			name:  "mixed examples keep order",
+			input: "\\exmp{1}{2}\\exmpfile{example.02}{example.02.a}{}\\exmp{3}{4}",
+			output: []latex.Example{
+				{Input: "1", Output: "2"},
+				{Input: "example.02", Output: "example.02.a", File: true},
+				{Input: "3", Output: "4"},
+			},
+		},
+		{
+			name:   "no examples",
+			input:  "Find a+b.",
+			output: nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := latex.Parse(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			got := latex.Examples(doc)
+			want := tc.output
+
+			if !cmp.Equal(want, got) {
+				t.Errorf("Examples do not match:\n%s\n", cmp.Diff(want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package latex
+
+import (
+	"io"
+)
+
+// Context defines how fragment is parsed by ParseFragment
+type Context int
+
+const (
+	BlockContext   Context = iota // fragment of document body: paragraphs and blocks
+	InlineContext                 // content of a paragraph
+	TabularContext                // rows of tabular environment
+	MathContext                   // content of a formula
+)
+
+// ParseFragment parses fragment of a document as if it was placed in the given context, for example rows of a table
+// without \\begin{tabular} and \\end{tabular}. It allows to reparse only a changed part of a document.
+//
+// Returned node depends on the context: document node for BlockContext, \\par for InlineContext, tabular node
+// (without colspec) for TabularContext and $ for MathContext.
+func ParseFragment(r Scanner, context Context, opts ...ParserOption) (*Node, error) {
+	return NewParser(r, opts...).ParseFragment(context)
+}
+
+// ParseFragment parses remaining input as a fragment in the given context, see ParseFragment
+func (p *Parser) ParseFragment(context Context) (*Node, error) {
+	eof := func(a any, err error) bool {
+		return err == io.EOF
+	}
+
+	switch context {
+	case InlineContext:
+		children, err := p.horizontal(eof)
+		if err != nil && (err != io.EOF || p.strict) {
+			return nil, p.fail(err)
+		}
+
+		return &Node{Kind: ElementKind, Data: "\\par", Children: children}, nil
+	case TabularContext:
+		rows, err := p.rows("", "")
+		if err != nil {
+			return nil, p.fail(err)
+		}
+
+		return &Node{Kind: ElementKind, Data: "tabular", Parameters: map[string]string{"colspec": ""}, Children: rows}, nil
+	case MathContext:
+		content, err := p.tokens.Verbatim(func(r rune, err error) bool {
+			return err == io.EOF
+		})
+		if err != nil {
+			return nil, p.fail(err)
+		}
+
+		return &Node{Kind: ElementKind, Data: "$", Children: []*Node{{Kind: TextKind, Data: content}}}, nil
+	default:
+		return p.Parse()
+	}
+}
@@ -0,0 +1,104 @@
+package latex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestParseFragment(t *testing.T) {
+	text := func(t string) *latex.Node {
+		return &latex.Node{Kind: latex.TextKind, Data: t}
+	}
+
+	par := func(children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.ElementKind, Data: "\\par", Children: children}
+	}
+
+	element := func(command string, children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.ElementKind, Data: command, Children: children}
+	}
+
+	elementp := func(command string, params map[string]string, children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.ElementKind, Data: command, Parameters: params, Children: children}
+	}
+
+	tt := []struct {
+		name    string
+		context latex.Context
+		input   string
+		output  *latex.Node
+	}{
+		{
+			name:    "block",
+			context: latex.BlockContext,
+			input:   "one\n\ntwo",
+			output:  &latex.Node{Kind: latex.DocumentKind, Children: []*latex.Node{par(text("one\n")), par(text("two"))}},
+		},
+		{
+			name:    "inline",
+			context: latex.InlineContext,
+			input:   "one \\textbf{two}",
+			output:  par(text("one "), element("\\textbf", text("two"))),
+		},
+		{
+			name:    "tabular",
+			context: latex.TabularContext,
+			input:   "a & b \\\\ \\hline\nc & \\textbf{d}",
+			output: elementp("tabular", map[string]string{"colspec": ""},
+				element("\\row",
+					element("\\cell", par(text("a "))),
+					element("\\cell", par(text(" b "))),
+				),
+				element("\\hline"),
+				element("\\row",
+					element("\\cell", par(text("c "))),
+					element("\\cell", par(text(" "), element("\\textbf", text("d")))),
+				),
+			),
+		},
+		{
+			name:    "tabular ending with multicolumn",
+			context: latex.TabularContext,
+			input:   "a & \\multicolumn{2}{c}{b}",
+			output: elementp("tabular", map[string]string{"colspec": ""},
+				element("\\row",
+					element("\\cell", par(text("a "))),
+					elementp("\\cell", map[string]string{"colspan": "2", "align": "c"}, par(text("b"))),
+				),
+			),
+		},
+		{
+			name:    "tabular with multicolumn",
+			context: latex.TabularContext,
+			input:   "\\multicolumn{2}{c}{Title}",
+			output: elementp("tabular", map[string]string{"colspec": ""},
+				element("\\row",
+					elementp("\\cell", map[string]string{"colspan": "2", "align": "c"}, par(text("Title"))),
+				),
+			),
+		},
+		{
+			name:    "math",
+			context: latex.MathContext,
+			input:   "a & b \\\\ \\frac{1}{2}",
+			output:  element("$", text("a & b \\\\ \\frac{1}{2}")),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := latex.ParseFragment(strings.NewReader(tc.input), tc.context)
+			if err != nil {
+				t.Fatalf("Unable to parse fragment: %v", err)
+			}
+
+			if !cmp.Equal(tc.output, got) {
+				t.Errorf("Tree does not match:\n%s", cmp.Diff(tc.output, got))
+			}
+		})
+	}
+}
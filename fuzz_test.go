@@ -0,0 +1,35 @@
+package latex_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/eolymp/go-latex"
+)
+
+func FuzzParse(f *testing.F) {
+	// test cases of the parser and documents rendered back as is are used as seed corpus
+	for _, tc := range parserTests() {
+		f.Add(tc.input)
+	}
+
+	for _, tc := range sourceTests {
+		f.Add(tc.input)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		doc, err := latex.Parse(strings.NewReader(input))
+		if err != nil {
+			return
+		}
+
+		if err := latex.Render(bytes.NewBuffer(nil), doc); err != nil {
+			t.Fatalf("Unable to render parsed document: %v", err)
+		}
+
+		if err := latex.RenderHTML(bytes.NewBuffer(nil), doc); err != nil {
+			t.Fatalf("Unable to render parsed document as HTML: %v", err)
+		}
+	})
+}
@@ -0,0 +1,749 @@
+package latex
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WithLabels sets labels used for section headings produced by markers like \\InputFile, \\OutputFile, \\Note etc.
+// Markers missing in the map keep their default English label.
+func WithLabels(labels map[string]string) RenderOption {
+	return func(o *renderOptions) {
+		merged := make(map[string]string, len(o.labels)+len(labels))
+		for marker, label := range o.labels {
+			merged[marker] = label
+		}
+
+		for marker, label := range labels {
+			merged[marker] = label
+		}
+
+		o.labels = merged
+	}
+}
+
+var defaultLabels = map[string]string{
+	"\\InputFile":   "Input",
+	"\\InputData":   "Input",
+	"\\OutputFile":  "Output",
+	"\\Note":        "Note",
+	"\\Scoring":     "Scoring",
+	"\\Interaction": "Interaction",
+	"\\Example":     "Example",
+	"\\Examples":    "Examples",
+	"theorem":       "Theorem",
+	"lemma":         "Lemma",
+	"proof":         "Proof",
+	"definition":    "Definition",
+	"corollary":     "Corollary",
+	"remark":        "Remark",
+}
+
+var htmlTags = map[string]string{
+	"\\textbf":           "b",
+	"\\bf":               "b",
+	"\\bfseries":         "b",
+	"\\textit":           "i",
+	"\\it":               "i",
+	"\\itshape":          "i",
+	"\\textsl":           "i",
+	"\\emph":             "em",
+	"\\underline":        "u",
+	"\\ul":               "u",
+	"\\sout":             "s",
+	"\\st":               "s",
+	"\\t":                "code",
+	"\\tt":               "code",
+	"\\texttt":           "code",
+	"\\textsuperscript":  "sup",
+	"\\textsubscript":    "sub",
+	"\\title":            "h1",
+	"\\chapter":          "h1",
+	"\\section":          "h2",
+	"\\subsection":       "h3",
+	"\\subsubsection":    "h4",
+	"\\subsubsubsection": "h5",
+	"\\caption":          "figcaption",
+}
+
+var htmlStyles = map[string]string{
+	"\\textmd":     "font-weight:normal",
+	"\\textup":     "font-style:normal",
+	"\\textrm":     "font-family:serif",
+	"\\textsf":     "font-family:sans-serif",
+	"\\textsc":     "font-variant:small-caps",
+	"\\tiny":       "font-size:xx-small",
+	"\\scriptsize": "font-size:x-small",
+	"\\small":      "font-size:small",
+	"\\normalsize": "font-size:medium",
+	"\\large":      "font-size:large",
+	"\\Large":      "font-size:x-large",
+	"\\LARGE":      "font-size:xx-large",
+	"\\huge":       "font-size:xx-large",
+	"\\Huge":       "font-size:xxx-large",
+	"\\phantom":    "visibility:hidden",
+	"\\hphantom":   "visibility:hidden;display:inline-block;height:0",
+	"\\vphantom":   "visibility:hidden;display:inline-block;width:0",
+}
+
+// RenderHTML renders document as HTML
+func RenderHTML(w io.Writer, node *Node, opts ...RenderOption) error {
+	r := &htmlRenderer{renderOptions: renderOptions{labels: defaultLabels, tabWidth: 8, sanitizer: DefaultURLSanitizer, inline: [2]string{"\\(", "\\)"}, block: [2]string{"\\[", "\\]"}}}
+	for _, opt := range opts {
+		opt(&r.renderOptions)
+	}
+
+	return r.render(w, node)
+}
+
+type htmlRenderer struct {
+	renderOptions
+}
+
+func (r *htmlRenderer) render(w io.Writer, node *Node) error {
+	switch node.Kind {
+	case DocumentKind:
+		return r.renderChildren(w, node)
+	case TextKind:
+		_, err := fmt.Fprint(w, html.EscapeString(node.Data))
+		return err
+	case ElementKind:
+		return r.renderElement(w, node)
+	default:
+		return nil
+	}
+}
+
+func (r *htmlRenderer) renderChildren(w io.Writer, node *Node) error {
+	for _, child := range node.Children {
+		if err := r.render(w, child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *htmlRenderer) renderChildrenAndWrap(w io.Writer, node *Node, prefix, suffix string) error {
+	if _, err := fmt.Fprint(w, prefix); err != nil {
+		return err
+	}
+
+	if err := r.renderChildren(w, node); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprint(w, suffix)
+	return err
+}
+
+func (r *htmlRenderer) renderVerbatimAndWrap(w io.Writer, node *Node, prefix, suffix string) error {
+	if _, err := fmt.Fprint(w, prefix); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprint(w, html.EscapeString(String(node))); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprint(w, suffix)
+	return err
+}
+
+func (r *htmlRenderer) renderElement(w io.Writer, node *Node) error {
+	if tag, ok := htmlTags[node.Data]; ok {
+		prefix := "<" + tag + ">"
+		if number := node.Parameters["number"]; number != "" {
+			prefix += html.EscapeString(number) + " "
+		}
+
+		return r.renderChildrenAndWrap(w, node, prefix, "</"+tag+">")
+	}
+
+	if style, ok := htmlStyles[node.Data]; ok {
+		return r.renderChildrenAndWrap(w, node, "<span style=\""+style+"\">", "</span>")
+	}
+
+	switch node.Data {
+	case "\\par":
+		return r.renderChildrenAndWrap(w, node, "<p>", "</p>\n")
+	case "\\\\", "\\\\*", "\\newline":
+		_, err := fmt.Fprint(w, "<br>\n")
+		return err
+	case "\\InputFile", "\\InputData", "\\OutputFile", "\\Note", "\\Scoring", "\\Interaction", "\\Example", "\\Examples":
+		// markers grouped by GroupSections own the content of their section
+		return r.renderChildrenAndWrap(w, node, "<h3>"+html.EscapeString(r.labels[node.Data])+"</h3>\n", "")
+	case "\\dots", "\\ldots":
+		_, err := fmt.Fprint(w, "…")
+		return err
+	case "\\cdots":
+		_, err := fmt.Fprint(w, "⋯")
+		return err
+	case "\\vdots":
+		_, err := fmt.Fprint(w, "⋮")
+		return err
+	case "\\ddots":
+		_, err := fmt.Fprint(w, "⋱")
+		return err
+	case "\\slash":
+		_, err := fmt.Fprint(w, "/<wbr>")
+		return err
+	case "\\heading":
+		level := node.Parameters["level"]
+		if level == "" {
+			level = "1"
+		}
+
+		return r.renderChildrenAndWrap(w, node, "<h"+level+">", "</h"+level+">")
+	case "\\showln":
+		return r.renderChildrenAndWrap(w, node, "<mark class=\"showln\">", "</mark>")
+	case "\\hl":
+		prefix := "<mark>"
+		if v := node.Parameters["color"]; isColor(v) {
+			prefix = "<mark style=\"background-color:" + v + "\">"
+		}
+
+		return r.renderChildrenAndWrap(w, node, prefix, "</mark>")
+	case "\\epigraph":
+		return r.renderChildrenAndWrap(w, node, "<blockquote class=\"epigraph\">", "</blockquote>\n")
+	case "\\epigraph:text":
+		return r.renderChildrenAndWrap(w, node, "<p>", "</p>")
+	case "\\epigraph:source":
+		return r.renderChildrenAndWrap(w, node, "<footer>", "</footer>")
+	case "\\vspace":
+		if px, err := MeasurePixels(node.Parameters["height"]); err == nil {
+			_, err := fmt.Fprintf(w, "<div style=\"height:%gpx\"></div>\n", px)
+			return err
+		}
+
+		return nil
+	case "\\hspace":
+		if px, err := MeasurePixels(node.Parameters["width"]); err == nil {
+			_, err := fmt.Fprintf(w, "<span style=\"display:inline-block;width:%gpx\"></span>", px)
+			return err
+		}
+
+		return nil
+	case "\\includegraphics":
+		src, ok := r.sanitize(node.Parameters["src"])
+		if !ok {
+			return nil
+		}
+
+		_, err := fmt.Fprint(w, "<img src=\"", html.EscapeString(src), "\"", r.dimensions(node.Parameters["options"]), ">\n")
+		return err
+	case "\\includemedia":
+		src, ok := r.sanitize(node.Parameters["src"])
+		if !ok {
+			return nil
+		}
+
+		_, err := fmt.Fprint(w, "<video src=\"", html.EscapeString(src), "\"", r.dimensions(node.Parameters["options"]), " controls></video>\n")
+		return err
+	case "\\exmp":
+		_, err := fmt.Fprint(w,
+			"<table class=\"example\">\n",
+			"<tr><th>", html.EscapeString(r.labels["\\InputFile"]), "</th><th>", html.EscapeString(r.labels["\\OutputFile"]), "</th></tr>\n",
+			"<tr><td><pre>", html.EscapeString(node.Parameters["input"]), "</pre></td><td><pre>", html.EscapeString(node.Parameters["output"]), "</pre></td></tr>\n",
+			"</table>\n",
+		)
+		return err
+	case "\\url":
+		text := html.EscapeString(node.Parameters["href"])
+
+		href, ok := r.link(node.Parameters["href"])
+		if !ok {
+			_, err := fmt.Fprint(w, text)
+			return err
+		}
+
+		_, err := fmt.Fprint(w, "<a href=\"", html.EscapeString(href), "\">", text, "</a>")
+		return err
+	case "\\href":
+		href, ok := r.link(node.Parameters["href"])
+		if !ok {
+			return r.renderChildren(w, node)
+		}
+
+		attrs := ""
+		if kv, err := KeyValue(node.Parameters["options"]); err == nil && isColor(kv["color"]) {
+			attrs = " style=\"color:" + kv["color"] + "\""
+		}
+
+		return r.renderChildrenAndWrap(w, node, "<a href=\""+html.EscapeString(href)+"\""+attrs+">", "</a>")
+	case "\\texorpdfstring":
+		return r.renderChildren(w, node)
+	case "\\user":
+		_, err := fmt.Fprint(w, "<span class=\"user\">", html.EscapeString(node.Parameters["nickname"]), "</span>")
+		return err
+	case "\\label":
+		_, err := fmt.Fprint(w, "<a id=\"", html.EscapeString(node.Parameters["label"]), "\"></a>")
+		return err
+	case "\\phantomsection":
+		_, err := fmt.Fprint(w, "<a id=\"", html.EscapeString(node.Parameters["anchor"]), "\"></a>")
+		return err
+	case "\\autoref", "\\nameref":
+		text := node.Parameters["name"]
+		if text == "" {
+			text = node.Parameters["label"]
+		}
+
+		_, err := fmt.Fprint(w, "<a href=\"#", html.EscapeString(node.Parameters["label"]), "\">", html.EscapeString(text), "</a>")
+		return err
+	case "\\raisebox":
+		style := "display:inline-block"
+		if px, err := MeasurePixels(node.Parameters["lift"]); err == nil {
+			style += fmt.Sprintf(";position:relative;bottom:%gpx", px)
+		}
+
+		return r.renderChildrenAndWrap(w, node, "<span style=\""+style+"\">", "</span>")
+	case "\\hyperref":
+		return r.renderChildrenAndWrap(w, node, "<a href=\"#"+html.EscapeString(node.Parameters["label"])+"\">", "</a>")
+	case "\\cite":
+		keys := strings.Split(node.Parameters["keys"], ",")
+		numbers := strings.Split(node.Parameters["numbers"], ",")
+
+		for index, key := range keys {
+			text := key
+			if len(numbers) == len(keys) && numbers[index] != "" {
+				text = numbers[index]
+			}
+
+			if note := node.Parameters["note"]; note != "" && index == len(keys)-1 {
+				text += ", " + note
+			}
+
+			if _, err := fmt.Fprint(w, "<a href=\"#cite-", html.EscapeString(key), "\">[", html.EscapeString(text), "]</a>"); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case "thebibliography":
+		return r.renderChildrenAndWrap(w, node, "<ol class=\"bibliography\">\n", "</ol>\n")
+	case "\\bibitem":
+		return r.renderChildrenAndWrap(w, node, "<li id=\"cite-"+html.EscapeString(node.Parameters["key"])+"\">", "</li>\n")
+	case "itemize":
+		return r.renderChildrenAndWrap(w, node, "<ul>\n", "</ul>\n")
+	case "enumerate":
+		attrs := ""
+		if v := node.Parameters["start"]; v != "" {
+			attrs += " start=\"" + html.EscapeString(v) + "\""
+		}
+
+		if style := listStyleType(node.Parameters["options"]); style != "" {
+			attrs += " style=\"list-style-type:" + style + "\""
+		}
+
+		return r.renderChildrenAndWrap(w, node, "<ol"+attrs+">\n", "</ol>\n")
+	case "\\item":
+		if v := node.Parameters["value"]; v != "" {
+			return r.renderChildrenAndWrap(w, node, "<li value=\""+html.EscapeString(v)+"\">", "</li>\n")
+		}
+
+		return r.renderChildrenAndWrap(w, node, "<li>", "</li>\n")
+	case "center":
+		return r.renderChildrenAndWrap(w, node, "<div style=\"text-align:center\">\n", "</div>\n")
+	case "flushleft":
+		return r.renderChildrenAndWrap(w, node, "<div style=\"text-align:left\">\n", "</div>\n")
+	case "flushright":
+		return r.renderChildrenAndWrap(w, node, "<div style=\"text-align:right\">\n", "</div>\n")
+	case "figure":
+		return r.renderChildrenAndWrap(w, node, "<figure>\n", "</figure>\n")
+	case "multicols":
+		return r.renderChildrenAndWrap(w, node, "<div style=\"column-count:"+html.EscapeString(node.Parameters["columns"])+"\">\n", "</div>\n")
+	case "minipage", "subfigure":
+		style := "display:inline-block;vertical-align:"
+		switch node.Parameters["position"] {
+		case "t":
+			style += "top"
+		case "b":
+			style += "bottom"
+		default:
+			style += "middle"
+		}
+
+		if width := cssWidth(node.Parameters["width"]); width != "" {
+			style += ";width:" + width
+		}
+
+		// subfigures are figures placed side by side, each with its own caption
+		tag := "div"
+		if node.Data == "subfigure" {
+			tag = "figure"
+		}
+
+		return r.renderChildrenAndWrap(w, node, "<"+tag+" style=\""+style+"\">\n", "</"+tag+">\n")
+	case "theorem", "lemma", "proof", "definition", "corollary", "remark":
+		// label is "Theorem 1 (name).", proof is labeled with its name if there is one: "Proof of Lemma 2."
+		label := r.labels[node.Data]
+		if number := node.Parameters["number"]; number != "" {
+			label += " " + number
+		}
+
+		if name, ok := node.Parameters["name"]; ok && node.Data == "proof" {
+			label = name
+		} else if ok {
+			label += " (" + name + ")"
+		}
+
+		tag := "b"
+		if node.Data == "proof" {
+			tag = "i"
+		}
+
+		return r.renderChildrenAndWrap(w, node, "<div class=\""+node.Data+"\">\n<"+tag+">"+html.EscapeString(label)+".</"+tag+">\n", "</div>\n")
+	case "wrapfigure":
+		float := "right"
+		if p := node.Parameters["position"]; p == "l" || p == "L" || p == "i" || p == "I" {
+			float = "left"
+		}
+
+		return r.renderChildrenAndWrap(w, node, "<figure style=\"float:"+float+"\">\n", "</figure>\n")
+	case "problem", "tutorial":
+		prefix := "<div class=\"" + node.Data + "\">\n"
+		if title := node.Parameters["title"]; title != "" {
+			prefix += "<h1>" + html.EscapeString(title) + "</h1>\n"
+		}
+
+		return r.renderChildrenAndWrap(w, node, prefix, "</div>\n")
+	case "tabular", "tabular*", "tabularx":
+		attrs := ""
+		if width := cssWidth(node.Parameters["width"]); width != "" {
+			attrs = " style=\"width:" + width + "\""
+		}
+
+		grid, err := TableGrid(node)
+		if err != nil {
+			return err
+		}
+
+		// colspec with custom column types expanded is stored in columns parameter
+		colspec := node.Parameters["colspec"]
+		if v, ok := node.Parameters["columns"]; ok {
+			colspec = v
+		}
+
+		if _, err := fmt.Fprint(w, "<table"+attrs+">\n", colgroup(colspec)); err != nil {
+			return err
+		}
+
+		// cells are rendered from the grid, so placeholders covered by \\multirow are skipped
+		for _, row := range grid {
+			if _, err := fmt.Fprint(w, "<tr>"); err != nil {
+				return err
+			}
+
+			for _, cell := range row {
+				if cell.Covered || cell.Node == nil {
+					continue
+				}
+
+				tag := "td"
+				if cell.Header {
+					tag = "th"
+				}
+
+				if err := r.renderCell(w, cell.Node, tag); err != nil {
+					return err
+				}
+			}
+
+			if _, err := fmt.Fprint(w, "</tr>\n"); err != nil {
+				return err
+			}
+		}
+
+		_, err = fmt.Fprint(w, "</table>\n")
+		return err
+	case "\\row":
+		return r.renderChildrenAndWrap(w, node, "<tr>", "</tr>\n")
+	case "\\cell":
+		return r.renderCell(w, node, "td")
+	case "$":
+		return r.renderVerbatimAndWrap(w, node, html.EscapeString(r.inline[0]), html.EscapeString(r.inline[1]))
+	case "$$":
+		return r.renderVerbatimAndWrap(w, node, html.EscapeString(r.block[0]), html.EscapeString(r.block[1]))
+	case "array", "cases", "matrix", "pmatrix", "bmatrix", "Bmatrix", "vmatrix", "Vmatrix", "smallmatrix":
+		// math environments are left as is to be typeset by MathJax
+		return r.renderVerbatimAndWrap(w, node, "\\begin{"+node.Data+"}", "\\end{"+node.Data+"}")
+	case "equation", "equation*", "align", "align*":
+		return r.renderVerbatimAndWrap(w, node, "\\begin{"+node.Data+"}", "\\end{"+node.Data+"}\n")
+	case "\\verb", "\\verb*":
+		return r.renderVerbatimAndWrap(w, node, "<code>", "</code>")
+	case "verbatim":
+		_, err := fmt.Fprint(w, "<pre><code>", r.verbatimContent(node), "</code></pre>\n")
+		return err
+	case "lstlisting":
+		return r.renderListing(w, node)
+	case "minted":
+		_, err := fmt.Fprint(w, "<pre><code class=\"language-", html.EscapeString(node.Parameters["language"]), "\">", r.verbatimContent(node), "</code></pre>\n")
+		return err
+	case "{}":
+		return r.renderChildren(w, node)
+	case "\\hline", "\\cline", "\\hskip", "\\vskip", "\\appendix", "\\addcontentsline", "\\documentclass", "\\usepackage", "\\exmpfile", "\\lstset", "\\newenvironment", "\\renewenvironment", "\\verbatiminput":
+		return nil
+	default:
+		// other environments are rendered as generic blocks
+		if node.Data != "" && node.Data[0] != '\\' && node.Data[0] != '$' {
+			return r.renderChildrenAndWrap(w, node, "<div class=\""+html.EscapeString(node.Data)+"\">\n", "</div>\n")
+		}
+
+		return nil
+	}
+}
+
+// verbatimContent returns escaped content of verbatim block with tabs expanded and indentation removed if requested
+func (r *htmlRenderer) verbatimContent(node *Node) string {
+	content := String(node)
+	if r.gobble {
+		content = gobbleIndent(content)
+	}
+
+	return html.EscapeString(expandTabs(content, r.tabWidth))
+}
+
+// renderListing renders lstlisting, listing with caption or label is wrapped in figure, lines are numbered if
+// "numbers" option is set (left or right)
+func (r *htmlRenderer) renderListing(w io.Writer, node *Node) error {
+	content := r.verbatimContent(node)
+
+	if numbers := node.Parameters["numbers"]; numbers == "left" || numbers == "right" {
+		number, err := strconv.Atoi(node.Parameters["firstnumber"])
+		if err != nil {
+			number = 1
+		}
+
+		lines := strings.SplitAfter(content, "\n")
+		for i, line := range lines {
+			if line == "" {
+				continue
+			}
+
+			lines[i] = "<span class=\"line-number\">" + strconv.Itoa(number+i) + "</span>" + line
+		}
+
+		content = strings.Join(lines, "")
+	}
+
+	caption, captioned := node.Parameters["caption"]
+	label, labeled := node.Parameters["label"]
+
+	if !captioned && !labeled {
+		_, err := fmt.Fprint(w, "<pre><code>", content, "</code></pre>\n")
+		return err
+	}
+
+	prefix := "<figure>\n"
+	if labeled {
+		prefix = "<figure id=\"" + html.EscapeString(label) + "\">\n"
+	}
+
+	if captioned {
+		prefix += "<figcaption>" + html.EscapeString(caption) + "</figcaption>\n"
+	}
+
+	_, err := fmt.Fprint(w, prefix, "<pre><code>", content, "</code></pre>\n</figure>\n")
+	return err
+}
+
+// link returns target of \\url or \\href, it's normalized with WithSafeLinks option and checked by URL sanitizer,
+// false is returned if target is rejected
+func (r *htmlRenderer) link(href string) (string, bool) {
+	href = escapeWhitespace(href)
+
+	if r.safe {
+		href = normalizeURL(href)
+	}
+
+	return r.sanitize(href)
+}
+
+// sanitize checks URL with URL sanitizer, false is returned if URL is rejected
+func (r *htmlRenderer) sanitize(url string) (string, bool) {
+	if r.sanitizer == nil {
+		return url, true
+	}
+
+	return r.sanitizer(url)
+}
+
+// isColor checks if value is a color name or a hex color code which can be safely used in CSS
+func isColor(value string) bool {
+	if strings.HasPrefix(value, "#") {
+		value = value[1:]
+	}
+
+	if value == "" {
+		return false
+	}
+
+	for _, char := range value {
+		if !isLetter(char) && !isDigit(char, 10) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dimensions converts width and height in graphics options to HTML attributes
+func (r *htmlRenderer) dimensions(options string) (attrs string) {
+	if options == "" {
+		return ""
+	}
+
+	kv, err := KeyValue(options)
+	if err != nil {
+		return ""
+	}
+
+	for _, key := range []string{"width", "height"} {
+		if px, err := MeasurePixels(kv[key]); err == nil {
+			attrs += fmt.Sprintf(" %s=\"%g\"", key, px)
+		}
+	}
+
+	return
+}
+
+// listStyleType converts enumerate options to CSS list-style-type
+func listStyleType(options string) string {
+	if options == "" {
+		return ""
+	}
+
+	style, _, _ := enumerateStyle(options)
+
+	switch style {
+	case "\\alph*":
+		return "lower-alpha"
+	case "\\Alph*":
+		return "upper-alpha"
+	case "\\roman*":
+		return "lower-roman"
+	case "\\Roman*":
+		return "upper-roman"
+	default:
+		return "decimal"
+	}
+}
+
+// cssWidth converts LaTeX width to CSS, widths relative to \\textwidth or \\linewidth are converted to percents,
+// others to pixels. It returns empty string if width can not be converted.
+func cssWidth(value string) string {
+	if width, unit, err := Measure(value); err == nil && (unit == "\\textwidth" || unit == "\\linewidth") {
+		return fmt.Sprintf("%g%%", width*100)
+	}
+
+	// pixels are rounded to hundredths to hide float32 rounding errors, e.g. 116.100006px for 3cm
+	if px, err := MeasurePixels(value); err == nil {
+		return strconv.FormatFloat(math.Round(float64(px)*100)/100, 'f', -1, 64) + "px"
+	}
+
+	return ""
+}
+
+// colgroup renders <colgroup> with widths of paragraph columns (p, m and b) defined by colspec, it returns empty
+// string if none of the columns has width
+func colgroup(colspec string) string {
+	out := ""
+	sized := false
+
+	for _, column := range ColumnSpecs(colspec) {
+		width := cssWidth(column.Width)
+		if width == "" {
+			out += "<col>"
+			continue
+		}
+
+		out += "<col style=\"width:" + width + "\">"
+		sized = true
+	}
+
+	if !sized {
+		return ""
+	}
+
+	return "<colgroup>" + out + "</colgroup>\n"
+}
+
+// expandTabs replaces tabs with spaces up to the next tab stop, tab stops are placed every width characters
+func expandTabs(text string, width int) string {
+	if width <= 0 || !strings.Contains(text, "\t") {
+		return text
+	}
+
+	out := strings.Builder{}
+	column := 0
+
+	for _, char := range text {
+		switch char {
+		case '\t':
+			spaces := width - column%width
+			out.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+		case '\n':
+			out.WriteRune(char)
+			column = 0
+		default:
+			out.WriteRune(char)
+			column++
+		}
+	}
+
+	return out.String()
+}
+
+// gobbleIndent removes leading whitespaces common to all non-blank lines, blank lines are left empty
+func gobbleIndent(text string) string {
+	lines := strings.Split(text, "\n")
+
+	indent := ""
+	found := false
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		prefix := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			indent, found = prefix, true
+			continue
+		}
+
+		for !strings.HasPrefix(prefix, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+			continue
+		}
+
+		lines[i] = line[len(indent):]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// renderCell renders table cell using given tag (td or th)
+func (r *htmlRenderer) renderCell(w io.Writer, node *Node, tag string) error {
+	attrs := ""
+	if v := node.Parameters["colspan"]; v != "" {
+		attrs += " colspan=\"" + html.EscapeString(v) + "\""
+	}
+
+	if v := node.Parameters["rowspan"]; v != "" {
+		attrs += " rowspan=\"" + html.EscapeString(v) + "\""
+	}
+
+	return r.renderChildrenAndWrap(w, node, "<"+tag+attrs+">", "</"+tag+">")
+}
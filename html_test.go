@@ -0,0 +1,490 @@
+package latex_test
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestRenderHTML(t *testing.T) {
+	doc := func(children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.DocumentKind, Children: children}
+	}
+
+	text := func(t string) *latex.Node {
+		return &latex.Node{Kind: latex.TextKind, Data: t}
+	}
+
+	par := func(children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.ElementKind, Data: "\\par", Children: children}
+	}
+
+	element := func(command string, children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.ElementKind, Data: command, Children: children}
+	}
+
+	tt := []struct {
+		name     string
+		render   string
+		options  []latex.RenderOption
+		document *latex.Node
+	}{
+		{
+			name:     "escaped text",
+			render:   "<p>a &lt; b &amp; c</p>\n",
+			document: doc(par(text("a < b & c"))),
+		},
+		{
+			name:     "breakable slash",
+			render:   "<p>input/<wbr>output</p>\n",
+			document: doc(par(text("input"), element("\\slash"), text("output"))),
+		},
+		{
+			name:   "highlight with color",
+			render: "<p><mark style=\"background-color:yellow\">a</mark> <mark>b</mark></p>\n",
+			document: doc(par(
+				&latex.Node{Kind: latex.ElementKind, Data: "\\hl", Parameters: map[string]string{"color": "yellow"}, Children: []*latex.Node{text("a")}},
+				text(" "),
+				&latex.Node{Kind: latex.ElementKind, Data: "\\hl", Parameters: map[string]string{"color": "red;background:url(https://evil/x)"}, Children: []*latex.Node{text("b")}},
+			)),
+		},
+		{
+			name:     "formatting",
+			render:   "<p>odd <b>foo <i>bar</i></b> baz</p>\n",
+			document: doc(par(text("odd "), element("\\textbf", text("foo "), element("\\textit", text("bar"))), text(" baz"))),
+		},
+		{
+			name:     "empty math",
+			render:   "<p>a \\(\\) b \\[\\]</p>\n",
+			document: doc(par(text("a "), element("$"), text(" b "), element("$$"))),
+		},
+		{
+			name:     "highlighted example line",
+			render:   "<p>3 <mark class=\"showln\">1 2 3</mark></p>\n",
+			document: doc(par(text("3 "), &latex.Node{Kind: latex.ElementKind, Data: "\\showln", Parameters: map[string]string{"highlight": "true"}, Children: []*latex.Node{text("1 2 3")}})),
+		},
+		{
+			name:     "math environment",
+			render:   "<p>\\begin{cases} a &amp; b \\\\ c &lt; d \\end{cases}</p>\n",
+			document: doc(par(element("cases", text(" a & b \\\\ c < d ")))),
+		},
+		{
+			name:     "equation",
+			render:   "\\begin{equation}x &lt; 1 \\tag{A}\\end{equation}\n",
+			document: doc(element("equation", text("x < 1 \\tag{A}"))),
+		},
+		{
+			name:   "table with target width",
+			render: "<table style=\"width:80%\">\n<tr><td><p>a</p>\n</td></tr>\n</table>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "tabularx", Parameters: map[string]string{"colspec": "X", "width": "0.8\\textwidth"}, Children: []*latex.Node{
+					element("\\row", element("\\cell", par(text("a")))),
+				}},
+			),
+		},
+		{
+			name:   "paragraph column width",
+			render: "<table>\n<colgroup><col style=\"width:116.1px\"><col></colgroup>\n<tr><td><p>a</p>\n</td><td><p>b</p>\n</td></tr>\n</table>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "tabular", Parameters: map[string]string{"colspec": "p{3cm}c"}, Children: []*latex.Node{
+					element("\\row", element("\\cell", par(text("a"))), element("\\cell", par(text("b")))),
+				}},
+			),
+		},
+		{
+			name:   "custom column type width",
+			render: "<table>\n<colgroup><col style=\"width:116.1px\"><col></colgroup>\n<tr><td><p>a</p>\n</td><td><p>b</p>\n</td></tr>\n</table>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "tabular", Parameters: map[string]string{"colspec": "Pc", "columns": "p{3cm}c"}, Children: []*latex.Node{
+					element("\\row", element("\\cell", par(text("a"))), element("\\cell", par(text("b")))),
+				}},
+			),
+		},
+		{
+			name:   "multicolumn cell",
+			render: "<table>\n<tr><td colspan=\"3\"><p>Title</p>\n</td><td><p>d</p>\n</td></tr>\n</table>\n",
+			document: doc(
+				element("tabular", element("\\row",
+					&latex.Node{Kind: latex.ElementKind, Data: "\\cell", Parameters: map[string]string{"colspan": "3", "align": "c"}, Children: []*latex.Node{par(text("Title"))}},
+					element("\\cell", par(text("d"))),
+				)),
+			),
+		},
+		{
+			name:   "multirow cell",
+			render: "<table>\n<tr><td rowspan=\"2\"><p>a</p>\n</td><td><p>b</p>\n</td></tr>\n<tr><td><p>c</p>\n</td></tr>\n</table>\n",
+			document: doc(
+				element("tabular",
+					element("\\row",
+						&latex.Node{Kind: latex.ElementKind, Data: "\\cell", Parameters: map[string]string{"rowspan": "2", "width": "*"}, Children: []*latex.Node{par(text("a"))}},
+						element("\\cell", par(text("b"))),
+					),
+					element("\\row",
+						element("\\cell", par(text(" "))),
+						element("\\cell", par(text("c"))),
+					),
+				),
+			),
+		},
+		{
+			name:   "table with header row",
+			render: "<table>\n<tr><th><p><b>Group</b></p>\n</th><th><p> <b>Points</b></p>\n</th></tr>\n<tr><td><p>1</p>\n</td><td><p>10</p>\n</td></tr>\n</table>\n",
+			document: doc(
+				element("tabular",
+					element("\\row",
+						element("\\cell", par(element("\\bf", text("Group")))),
+						element("\\cell", par(text(" "), element("\\textbf", text("Points")))),
+					),
+					element("\\row",
+						element("\\cell", par(text("1"))),
+						element("\\cell", par(text("10"))),
+					),
+				),
+			),
+		},
+		{
+			name:   "verse",
+			render: "<div class=\"verse\">\n<p>Line one <br>\nLine two</p>\n</div>\n",
+			document: doc(
+				element("verse", par(text("Line one "), element("\\\\"), text("Line two"))),
+			),
+		},
+		{
+			name:   "numbered theorem with name",
+			render: "<div class=\"theorem\">\n<b>Theorem 2 (Pythagoras).</b>\n<p>a &lt; b</p>\n</div>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "theorem", Parameters: map[string]string{"name": "Pythagoras", "number": "2"}, Children: []*latex.Node{par(text("a < b"))}},
+			),
+		},
+		{
+			name:   "proof",
+			render: "<div class=\"proof\">\n<i>Proof.</i>\n<p>Trivial.</p>\n</div>\n<div class=\"proof\">\n<i>Proof of Lemma 1.</i>\n<p>Trivial.</p>\n</div>\n",
+			document: doc(
+				element("proof", par(text("Trivial."))),
+				&latex.Node{Kind: latex.ElementKind, Data: "proof", Parameters: map[string]string{"name": "Proof of Lemma 1"}, Children: []*latex.Node{par(text("Trivial."))}},
+			),
+		},
+		{
+			name:   "texorpdfstring",
+			render: "<p>\\(\\alpha\\)</p>\n",
+			document: doc(
+				par(&latex.Node{Kind: latex.ElementKind, Data: "\\texorpdfstring", Parameters: map[string]string{"pdfstring": "alpha"}, Children: []*latex.Node{element("$", text("\\alpha"))}}),
+			),
+		},
+		{
+			name:   "verbatim with tabs",
+			render: "<pre><code>int main() {\n        return 0;\n}\nab      c\n</code></pre>\n",
+			document: doc(
+				element("verbatim", text("int main() {\n\treturn 0;\n}\nab\tc\n")),
+			),
+		},
+		{
+			name:    "lstlisting with custom tab width",
+			render:  "<pre><code>int main() {\n    return 0;\n}\nab  c\n</code></pre>\n",
+			options: []latex.RenderOption{latex.WithTabWidth(4)},
+			document: doc(
+				element("lstlisting", text("int main() {\n\treturn 0;\n}\nab\tc\n")),
+			),
+		},
+		{
+			name:    "verbatim with tabs kept",
+			render:  "<pre><code>\tx\n</code></pre>\n",
+			options: []latex.RenderOption{latex.WithTabWidth(0)},
+			document: doc(
+				element("verbatim", text("\tx\n")),
+			),
+		},
+		{
+			name:   "lstlisting with whitespace prefix",
+			render: "<pre><code>    int a, b;\n    std::cin &gt;&gt; a &gt;&gt; b;\n</code></pre>\n",
+			document: doc(
+				element("lstlisting", text("    int a, b;\n    std::cin >> a >> b;\n")),
+			),
+		},
+		{
+			name:    "lstlisting with gobbled indent",
+			render:  "<pre><code>int main() {\n  return 0;\n\n}\n</code></pre>\n",
+			options: []latex.RenderOption{latex.WithGobbleIndent(true)},
+			document: doc(
+				element("lstlisting", text("    int main() {\n      return 0;\n  \n    }\n")),
+			),
+		},
+		{
+			name:    "verbatim with gobbled mixed indent",
+			render:  "<pre><code>  a\n        b\n</code></pre>\n",
+			options: []latex.RenderOption{latex.WithGobbleIndent(true)},
+			document: doc(
+				element("verbatim", text("  \t  a\n  \t\tb\n")),
+			),
+		},
+		{
+			name:   "lstlisting with caption and line numbers",
+			render: "<figure id=\"lst:sum\">\n<figcaption>Sum &lt; 10</figcaption>\n<pre><code><span class=\"line-number\">10</span>a = 1\n<span class=\"line-number\">11</span>print(a)\n</code></pre>\n</figure>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "lstlisting", Parameters: map[string]string{"caption": "Sum < 10", "label": "lst:sum", "numbers": "left", "firstnumber": "10"}, Children: []*latex.Node{text("a = 1\nprint(a)\n")}},
+			),
+		},
+		{
+			name:   "lstlisting without line numbers",
+			render: "<pre><code>a = 1\n</code></pre>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "lstlisting", Parameters: map[string]string{"numbers": "none"}, Children: []*latex.Node{text("a = 1\n")}},
+			),
+		},
+		{
+			name:   "minted",
+			render: "<pre><code class=\"language-python\">print(1 &lt; 2)\n</code></pre>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "minted", Parameters: map[string]string{"language": "python"}, Children: []*latex.Node{text("print(1 < 2)\n")}},
+			),
+		},
+		{
+			name:   "links",
+			render: "<p><a href=\"//eolymp.com\">//eolymp.com</a> x</p>\n",
+			document: doc(par(
+				&latex.Node{Kind: latex.ElementKind, Data: "\\url", Parameters: map[string]string{"href": "//eolymp.com"}},
+				text(" "),
+				&latex.Node{Kind: latex.ElementKind, Data: "\\href", Parameters: map[string]string{"href": "javascript:alert(1)"}, Children: []*latex.Node{text("x")}},
+			)),
+		},
+		{
+			name:    "links without sanitizer",
+			render:  "<p><a href=\"//eolymp.com\">//eolymp.com</a> <a href=\"javascript:alert(1)\">x</a></p>\n",
+			options: []latex.RenderOption{latex.WithURLSanitizer(nil)},
+			document: doc(par(
+				&latex.Node{Kind: latex.ElementKind, Data: "\\url", Parameters: map[string]string{"href": "//eolymp.com"}},
+				text(" "),
+				&latex.Node{Kind: latex.ElementKind, Data: "\\href", Parameters: map[string]string{"href": "javascript:alert(1)"}, Children: []*latex.Node{text("x")}},
+			)),
+		},
+		{
+			name:   "colored link",
+			render: "<p><a href=\"https://eolymp.com\" style=\"color:#ff0000\">a</a> <a href=\"https://eolymp.com\">b</a></p>\n",
+			document: doc(par(
+				&latex.Node{Kind: latex.ElementKind, Data: "\\href", Parameters: map[string]string{"href": "https://eolymp.com", "options": "color=#ff0000"}, Children: []*latex.Node{text("a")}},
+				text(" "),
+				&latex.Node{Kind: latex.ElementKind, Data: "\\href", Parameters: map[string]string{"href": "https://eolymp.com", "options": "color=\"red;display:none\""}, Children: []*latex.Node{text("b")}},
+			)),
+		},
+		{
+			name:   "sanitized images",
+			render: "<img src=\"eolymp.png\">\n<img src=\"data:image/png;base64,iVBORw0KGgo=\">\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "\\includegraphics", Parameters: map[string]string{"src": "eolymp.png"}},
+				&latex.Node{Kind: latex.ElementKind, Data: "\\includegraphics", Parameters: map[string]string{"src": "data:image/png;base64,iVBORw0KGgo="}},
+				&latex.Node{Kind: latex.ElementKind, Data: "\\includegraphics", Parameters: map[string]string{"src": "data:image/svg+xml,<svg onload=alert(1)>"}},
+				&latex.Node{Kind: latex.ElementKind, Data: "\\includemedia", Parameters: map[string]string{"src": "javascript:alert(1)"}},
+			),
+		},
+		{
+			name:    "custom URL sanitizer",
+			render:  "<p><a href=\"https://proxy.eolymp.com/?url=https://example.com\">https://example.com</a></p>\n",
+			options: []latex.RenderOption{latex.WithURLSanitizer(func(url string) (string, bool) { return "https://proxy.eolymp.com/?url=" + url, true })},
+			document: doc(par(
+				&latex.Node{Kind: latex.ElementKind, Data: "\\url", Parameters: map[string]string{"href": "https://example.com"}},
+			)),
+		},
+		{
+			name:     "text superscript and subscript",
+			render:   "<p>1<sup>st</sup> place, H<sub>2</sub>O</p>\n",
+			document: doc(par(text("1"), element("\\textsuperscript", text("st")), text(" place, H"), element("\\textsubscript", text("2")), text("O"))),
+		},
+		{
+			name:     "math",
+			render:   "<p>Let \\(x &lt; 1\\)</p>\n\\[x^2\\]",
+			document: doc(par(text("Let "), element("$", text("x < 1"))), element("$$", text("x^2"))),
+		},
+		{
+			name:     "math with dollar delimiters",
+			render:   "<p>Let $x &lt; 1$</p>\n$$x^2$$",
+			options:  []latex.RenderOption{latex.WithMathDelimiters([2]string{"$", "$"}, [2]string{"$$", "$$"})},
+			document: doc(par(text("Let "), element("$", text("x < 1"))), element("$$", text("x^2"))),
+		},
+		{
+			name:   "link with space",
+			render: "<p><a href=\"https://example.com/my%20file.pdf\">file</a></p>\n",
+			document: doc(par(
+				&latex.Node{Kind: latex.ElementKind, Data: "\\href", Parameters: map[string]string{"href": "https://example.com/my file.pdf"}, Children: []*latex.Node{text("file")}},
+			)),
+		},
+		{
+			name:   "link with whitespace and control characters",
+			render: "<p><a href=\"https://example.com/a%09b%0Ac%01d%C2%A0e\">file</a></p>\n",
+			document: doc(par(
+				&latex.Node{Kind: latex.ElementKind, Data: "\\href", Parameters: map[string]string{"href": " https://example.com/a\tb\nc\x01d\u00A0e\n"}, Children: []*latex.Node{text("file")}},
+			)),
+		},
+		{
+			name:    "safe links",
+			render:  "<p><a href=\"mailto:support@eolymp.com\">mailto:support@eolymp.com</a> <a href=\"https://eolymp.com/problems\">//eolymp.com/problems</a> <a href=\"https://eolymp.com\">eolymp.com</a> <a href=\"http://eolymp.com\">site</a> <a href=\"#intro\">intro</a> x JavaScript:alert(1)</p>\n",
+			options: []latex.RenderOption{latex.WithSafeLinks()},
+			document: doc(par(
+				&latex.Node{Kind: latex.ElementKind, Data: "\\url", Parameters: map[string]string{"href": "mailto:support@eolymp.com"}},
+				text(" "),
+				&latex.Node{Kind: latex.ElementKind, Data: "\\url", Parameters: map[string]string{"href": "//eolymp.com/problems"}},
+				text(" "),
+				&latex.Node{Kind: latex.ElementKind, Data: "\\url", Parameters: map[string]string{"href": "eolymp.com"}},
+				text(" "),
+				&latex.Node{Kind: latex.ElementKind, Data: "\\href", Parameters: map[string]string{"href": "http://eolymp.com"}, Children: []*latex.Node{text("site")}},
+				text(" "),
+				&latex.Node{Kind: latex.ElementKind, Data: "\\href", Parameters: map[string]string{"href": "#intro"}, Children: []*latex.Node{text("intro")}},
+				text(" "),
+				&latex.Node{Kind: latex.ElementKind, Data: "\\href", Parameters: map[string]string{"href": "javascript:alert(1)"}, Children: []*latex.Node{text("x")}},
+				text(" "),
+				&latex.Node{Kind: latex.ElementKind, Data: "\\url", Parameters: map[string]string{"href": "JavaScript:alert(1)"}},
+			)),
+		},
+		{
+			name:   "minipage",
+			render: "<div style=\"display:inline-block;vertical-align:top;width:50%\">\n<p>Left</p>\n</div>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "minipage", Parameters: map[string]string{"position": "t", "width": "0.5\\textwidth"}, Children: []*latex.Node{par(text("Left"))}},
+			),
+		},
+		{
+			name:   "subfigure",
+			render: "<figure style=\"display:inline-block;vertical-align:bottom;width:50%\">\n<p><figcaption>A</figcaption></p>\n</figure>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "subfigure", Parameters: map[string]string{"position": "b", "width": "0.5\\textwidth"}, Children: []*latex.Node{par(element("\\caption", text("A")))}},
+			),
+		},
+		{
+			name:   "multicols",
+			render: "<div style=\"column-count:3\">\n<p>Text</p>\n</div>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "multicols", Parameters: map[string]string{"columns": "3"}, Children: []*latex.Node{par(text("Text"))}},
+			),
+		},
+		{
+			name:   "flush environments",
+			render: "<div style=\"text-align:left\">\n<p>Left</p>\n</div>\n<div style=\"text-align:right\">\n<p>Right</p>\n</div>\n",
+			document: doc(
+				element("flushleft", par(text("Left"))),
+				element("flushright", par(text("Right"))),
+			),
+		},
+		{
+			name:   "numbered section",
+			render: "<h3>2.1 Data</h3>",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "\\subsection", Parameters: map[string]string{"number": "2.1"}, Children: []*latex.Node{text("Data")}},
+			),
+		},
+		{
+			name:   "section markers",
+			render: "<h3>Input</h3>\n<p>one</p>\n<h3>Output</h3>\n<p>two</p>\n<h3>Note</h3>\n",
+			document: doc(
+				element("\\InputFile"),
+				par(text("one")),
+				element("\\OutputFile"),
+				par(text("two")),
+				element("\\Note"),
+			),
+		},
+		{
+			name:   "enumerate with label style",
+			render: "<ol style=\"list-style-type:lower-alpha\">\n<li><p>First</p>\n</li>\n</ol>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "enumerate", Parameters: map[string]string{"options": "label=(\\alph*)"}, Children: []*latex.Node{
+					element("\\item", par(text("First"))),
+				}},
+			),
+		},
+		{
+			name:   "enumerate with start and item value",
+			render: "<ol start=\"3\">\n<li><p>First</p>\n</li>\n<li value=\"10\"><p>Second</p>\n</li>\n</ol>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "enumerate", Parameters: map[string]string{"start": "3"}, Children: []*latex.Node{
+					element("\\item", par(text("First"))),
+					&latex.Node{Kind: latex.ElementKind, Data: "\\item", Parameters: map[string]string{"value": "10"}, Children: []*latex.Node{par(text("Second"))}},
+				}},
+			),
+		},
+		{
+			name:   "sample table",
+			render: "<table class=\"example\">\n<tr><th>Input</th><th>Output</th></tr>\n<tr><td><pre>1 &lt; 2\n</pre></td><td><pre>YES\n</pre></td></tr>\n</table>\n",
+			document: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "\\exmp", Parameters: map[string]string{"input": "1 < 2\n", "output": "YES\n"}},
+			),
+		},
+		{
+			name:    "localized section markers",
+			render:  "<h3>Вхідні дані</h3>\n<h3>Вихідні дані</h3>\n<h3>Примітка</h3>\n<h3>Scoring</h3>\n",
+			options: []latex.RenderOption{latex.WithLabels(map[string]string{"\\InputFile": "Вхідні дані", "\\OutputFile": "Вихідні дані", "\\Note": "Примітка"})},
+			document: doc(
+				element("\\InputFile"),
+				element("\\OutputFile"),
+				element("\\Note"),
+				element("\\Scoring"),
+			),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			b := bytes.NewBuffer(nil)
+
+			if err := latex.RenderHTML(b, tc.document, tc.options...); err != nil {
+				t.Fatal(err)
+			}
+
+			if b.String() != tc.render {
+				t.Errorf("Rendered HTML does not match:\nwant: %q\n got: %q", tc.render, b.String())
+			}
+		})
+	}
+}
+
+func TestRenderHTML_HeaderCells(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		cells []string // tag of cells in each row, e.g. "th th" for a header row of two columns
+	}{
+		{
+			name:  "cf33",
+			input: "Scoring table example:\n\\begin{center}\n  \\begin{tabular}{ | c | c | c | c | } \\hline\n    \\bf{Group} &\n    \\bf{Add. constraints} &\n    \\bf{Points} &\n    \\bf{Req. groups} \\\\ \\hline\n    $1$ & $b = a + 1$ & $30$ & --- \\\\ \\hline\n    $2$ & $n \\le 1\\,000$ & $10$ & examples \\\\ \\hline\n    $3$ & $n \\le 10^7$ & $20$ & $2$ \\\\ \\hline\n    $4$ & --- & $40$ & $1$, $3$ \\\\ \\hline\n  \\end{tabular}\n\\end{center}",
+			cells: []string{"th th th th", "td td td td", "td td td td", "td td td td", "td td td td"},
+		},
+		{
+			name:  "bfseries declaration",
+			input: "\\begin{tabular}{lll}\n\\bfseries Group & {\\bfseries Points} & \\textbf{Req.} groups \\\\\n1 & 10 & ---\n\\end{tabular}",
+			cells: []string{"th th td", "td td td"},
+		},
+	}
+
+	cell := regexp.MustCompile(`<(th|td)[ >]`)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := latex.Parse(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			b := bytes.NewBuffer(nil)
+			if err := latex.RenderHTML(b, doc); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, row := range strings.Split(b.String(), "<tr>")[1:] {
+				var tags []string
+				for _, match := range cell.FindAllStringSubmatch(row, -1) {
+					tags = append(tags, match[1])
+				}
+
+				got = append(got, strings.Join(tags, " "))
+			}
+
+			if !cmp.Equal(tc.cells, got) {
+				t.Errorf("Cell tags do not match:\n%s", cmp.Diff(tc.cells, got))
+			}
+		})
+	}
+}
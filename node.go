@@ -13,4 +13,32 @@ type Node struct {
 	Parameters map[string]string
 	Data       string
 	Children   []*Node
+	Span       Span // range in the source node was parsed from, it's set only if parser has WithSourceMap option
+}
+
+// Span is a range in the source, in bytes
+type Span struct {
+	Start int // offset of the first byte
+	End   int // offset after the last byte
+}
+
+// Clone returns a deep copy of the node, its parameters and children
+func (n *Node) Clone() *Node {
+	clone := *n
+
+	if n.Parameters != nil {
+		clone.Parameters = make(map[string]string, len(n.Parameters))
+		for key, value := range n.Parameters {
+			clone.Parameters[key] = value
+		}
+	}
+
+	if n.Children != nil {
+		clone.Children = make([]*Node, len(n.Children))
+		for i, child := range n.Children {
+			clone.Children[i] = child.Clone()
+		}
+	}
+
+	return &clone
 }
@@ -12,12 +12,124 @@ import (
 const cmInPixel = 38.7
 
 var identifier = regexp.MustCompile("^\\\\[a-zA-Z]+$")
-var escSeq = map[string]string{"\\\\": "\\", "\\{": "{", "\\}": "}", "\\[": "[", "\\]": "]"}
+
+var escSeq = strings.NewReplacer("\\\\", "\\", "\\{", "{", "\\}", "}", "\\[", "[", "\\]", "]")
+
+// urlSeq unescapes URLs, in addition to escSeq escaped % and # are common in URLs, where TeX would treat them as
+// a comment or a parameter, and escaped spaces
+var urlSeq = strings.NewReplacer("\\\\", "\\", "\\{", "{", "\\}", "}", "\\[", "[", "\\]", "]", "\\%", "%", "\\#", "#", "\\ ", " ")
 
 type Parser struct {
-	strict bool
-	tokens *Tokenizer
-	defs   map[string]string
+	strict        bool
+	tokens        *Tokenizer
+	defs          map[string]string
+	aliases       map[Command]Command    // commands made aliases of other commands by \\let
+	phantoms      int                    // number of \\phantomsection commands, used to generate anchors
+	tables        int                    // depth of nested tables, groups inside table cells are closed at cell boundary
+	verses        int                    // depth of nested verse environments, line breaks inside verses don't split paragraphs
+	conditions    int                    // depth of nested conditionals (\\iftrue etc.) which branch is being parsed
+	counters      map[string]int         // counters defined by \\newcounter and counters of enumerate environments
+	enumerates    int                    // depth of nested enumerate environments, it selects counter (enumi, enumii etc.)
+	columnTypes   map[string]columnType  // custom column types defined by \\newcolumntype
+	listing       map[string]string      // default listing options set by \\lstset
+	environments  map[string]environment // custom environments defined by \\newenvironment
+	macros        map[string]macro       // commands with arguments defined by \\newcommand
+	expansions    int                    // depth of nested custom environment expansions
+	expanded      int                    // total size of code produced by custom environment expansions, in bytes
+	origin        int64                  // offset of the custom environment being expanded, diagnostics point to it
+	keepEnvs      bool                   // keep \\newenvironment declarations instead of expanding custom environments
+	softHyphens   bool                   // emit soft hyphens for \\- instead of dropping them
+	literalTie    bool                   // keep ~ as is instead of replacing it with non-breaking space
+	loader        Loader                 // loads files included by \\verbatiminput
+	diagnostics   []Diagnostic           // errors parser has recovered from in non-strict mode
+	recoveryLimit int                    // number of iterations parser can make without consuming input
+	validate      bool                   // validate tables against their colspec
+	sourceMap     bool                   // record source spans of nodes
+}
+
+// ParserOption configures parser
+type ParserOption func(*Parser)
+
+// WithRecoveryLimit sets number of consecutive iterations parser can make without consuming any input before it
+// gives up with an error. It guarantees parsing terminates on any input.
+func WithRecoveryLimit(n int) ParserOption {
+	return func(p *Parser) {
+		p.recoveryLimit = n
+	}
+}
+
+// WithTableValidation enables validation of tables, rows having more cells than columns defined in the colspec
+// are reported as diagnostics.
+func WithTableValidation() ParserOption {
+	return func(p *Parser) {
+		p.validate = true
+	}
+}
+
+// WithLenientMath makes parser treat math which is not closed at the end of input (like "$a+b") as closed, instead of
+// reading opening $ as text.
+func WithLenientMath() ParserOption {
+	return func(p *Parser) {
+		p.tokens.lenient = true
+	}
+}
+
+// WithSourceMap enables recording of source spans (see Node.Span), editors can use them to map rendered elements
+// back to the source.
+func WithSourceMap() ParserOption {
+	return func(p *Parser) {
+		p.sourceMap = true
+	}
+}
+
+// WithKeepEnvironmentDefinitions keeps \\newenvironment declarations in the document and parses custom environments
+// like any other unknown environment instead of expanding them, so the document renders back as written.
+func WithKeepEnvironmentDefinitions() ParserOption {
+	return func(p *Parser) {
+		p.keepEnvs = true
+	}
+}
+
+// WithSoftHyphens makes parser emit soft hyphen (U+00AD) for discretionary hyphens \\-, by default they are dropped.
+func WithSoftHyphens() ParserOption {
+	return func(p *Parser) {
+		p.softHyphens = true
+	}
+}
+
+// WithTieAsNBSP controls how tie ~ is parsed: as non-breaking space (U+00A0), which is the default, or as literal ~.
+func WithTieAsNBSP(enabled bool) ParserOption {
+	return func(p *Parser) {
+		p.literalTie = !enabled
+	}
+}
+
+// Loader loads content of files included by the document, like \\verbatiminput{file}
+type Loader func(name string) (string, error)
+
+// WithLoader sets loader for files included by the document. Without loader included files are kept as references
+// to the file.
+func WithLoader(loader Loader) ParserOption {
+	return func(p *Parser) {
+		p.loader = loader
+	}
+}
+
+const defaultRecoveryLimit = 100
+
+// maxEnvironmentExpansions limits nesting of custom environments, so recursive definitions do not hang the parser
+const maxEnvironmentExpansions = 10
+
+// maxExpandedBytes limits total size of code produced by custom environments in one document
+const maxExpandedBytes = 1 << 20
+
+// errExpansionLimit is returned when custom environments exceed maxExpandedBytes, parser can't recover from it
+var errExpansionLimit = fmt.Errorf("custom environments expand to more than %d bytes", maxExpandedBytes)
+
+// Diagnostic describes an error parser has recovered from
+type Diagnostic struct {
+	Offset  int64 // offset in bytes, position in the input where error was discovered
+	Message string
 }
 
 func Parse(r Scanner) (*Node, error) {
@@ -28,37 +140,155 @@ func Strict(r Scanner) (*Node, error) {
 	return NewStrictParser(r).Parse()
 }
 
-func NewParser(r Scanner) *Parser {
-	return &Parser{tokens: NewTokenizer(r), defs: map[string]string{}}
+func NewParser(r Scanner, opts ...ParserOption) *Parser {
+	p := &Parser{tokens: NewTokenizer(r), defs: map[string]string{}, aliases: map[Command]Command{}, counters: map[string]int{}, columnTypes: map[string]columnType{}, listing: map[string]string{}, environments: map[string]environment{}, macros: map[string]macro{}, recoveryLimit: defaultRecoveryLimit}
+	for _, name := range enumerateCounters {
+		p.counters[name] = 0
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
 }
 
-func NewStrictParser(r Scanner) *Parser {
-	return &Parser{strict: true, tokens: NewTokenizer(r), defs: map[string]string{}}
+func NewStrictParser(r Scanner, opts ...ParserOption) *Parser {
+	p := NewParser(r, opts...)
+	p.strict = true
+
+	return p
 }
 
 func (p *Parser) Define(key, val string) {
 	p.defs[key] = val
+	delete(p.aliases, Command(key))
+	delete(p.macros, key)
 }
 
 func (p *Parser) Value(key string) string {
 	return p.defs[key]
 }
 
+// Diagnostics returns errors parser has recovered from, in strict mode these errors are returned by Parse
+func (p *Parser) Diagnostics() []Diagnostic {
+	return p.diagnostics
+}
+
+// progress returns a function which should be called on each iteration of parsing loop, it fails if parser makes too
+// many iterations without consuming any input
+func (p *Parser) progress() func() error {
+	offset, stalls := int64(-1), 0
+
+	return func() error {
+		current := p.tokens.Offset()
+		if current != offset {
+			offset, stalls = current, 0
+			return nil
+		}
+
+		stalls++
+		if stalls > p.recoveryLimit {
+			return fmt.Errorf("parser does not make progress at offset %d", current)
+		}
+
+		return nil
+	}
+}
+
+// recover records error as a diagnostic, so parsing can continue
+func (p *Parser) recover(err error) {
+	p.diagnostics = append(p.diagnostics, Diagnostic{Offset: p.offset(), Message: err.Error()})
+}
+
+// fail attaches current position to the error returned by parser
+func (p *Parser) fail(err error) error {
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		return err
+	}
+
+	return &ParseError{Offset: p.offset(), Err: err}
+}
+
+// offset returns position where error was discovered
+func (p *Parser) offset() int64 {
+	if p.expansions > 0 {
+		// position in the expanded code is meaningless for the user
+		return p.origin
+	}
+
+	return p.tokens.Offset()
+}
+
 func (p *Parser) Parse() (*Node, error) {
 	children, _, err := p.vertical(func(a any, err error) bool {
 		return err == io.EOF
 	})
 
 	if err != nil && (err != io.EOF || p.strict) {
-		return nil, err
+		return nil, p.fail(err)
 	}
 
 	return &Node{Kind: DocumentKind, Children: children}, nil
 }
 
+// ParseDocument parses full LaTeX file, see Parser.ParseDocument
+func ParseDocument(r Scanner) (preamble *Node, body *Node, err error) {
+	return NewParser(r).ParseDocument()
+}
+
+// ParseDocument parses full LaTeX file: everything before \\begin{document} is returned as preamble and content
+// of the document environment is returned as body. Definitions made in preamble are applied to the body.
+// If there is no document environment, whole input is treated as body.
+func (p *Parser) ParseDocument() (*Node, *Node, error) {
+	preamble, last, err := p.vertical(func(a any, err error) bool {
+		if err == io.EOF {
+			return true
+		}
+
+		n, ok := a.(EnvironmentStart)
+		return err == nil && ok && n.Name == "document"
+	})
+
+	if err != nil {
+		return nil, nil, p.fail(err)
+	}
+
+	// there is no document environment, so it's all body
+	if last == nil {
+		return &Node{Kind: DocumentKind}, &Node{Kind: DocumentKind, Children: preamble}, nil
+	}
+
+	body, last, err := p.vertical(func(a any, err error) bool {
+		if err == io.EOF {
+			return true
+		}
+
+		n, ok := a.(EnvironmentEnd)
+		return err == nil && ok && n.Name == "document"
+	})
+
+	if err != nil {
+		return nil, nil, p.fail(err)
+	}
+
+	if last == nil && p.strict {
+		return nil, nil, p.fail(errors.New("document environment is not closed"))
+	}
+
+	return &Node{Kind: DocumentKind, Children: preamble}, &Node{Kind: DocumentKind, Children: body}, nil
+}
+
 // horizontal collects text span nodes, it expects to discover text fragments which will be displayed horizontally (one next to another)
 func (p *Parser) horizontal(stop func(any, error) bool) (children []*Node, err error) {
+	progress := p.progress()
+
 	for {
+		if err := progress(); err != nil {
+			return nil, err
+		}
+
 		t, err := p.tokens.Token()
 		if stop(t, err) {
 			return children, nil
@@ -68,12 +298,15 @@ func (p *Parser) horizontal(stop func(any, error) bool) (children []*Node, err e
 			return nil, err
 		}
 
+		start := p.tokens.Start()
+
 		node, inline, err := p.parse(t)
 		if err != nil {
-			if p.strict {
+			if p.strict || errors.Is(err, errExpansionLimit) {
 				return nil, err
 			}
 
+			p.recover(err)
 			continue
 		}
 
@@ -81,17 +314,22 @@ func (p *Parser) horizontal(stop func(any, error) bool) (children []*Node, err e
 			continue
 		}
 
+		p.span(node, start)
+
 		if !inline {
+			err := errors.New("block token in horizontal mode")
 			if p.strict {
-				return nil, errors.New("block token in horizontal mode")
+				return nil, err
 			}
 
+			p.recover(err)
 			continue
 		}
 
 		// merge consequent text nodes together
 		if node.Kind == TextKind && len(children) > 0 && children[len(children)-1].Kind == TextKind {
 			children[len(children)-1].Data += node.Data
+			children[len(children)-1].Span.End = node.Span.End
 			continue
 		}
 
@@ -109,6 +347,10 @@ func (p *Parser) vertical(stop func(any, error) bool) (children []*Node, last an
 			return
 		}
 
+		if p.sourceMap {
+			floating.Span = Span{Start: floating.Children[0].Span.Start, End: floating.Children[len(floating.Children)-1].Span.End}
+		}
+
 		children = append(children, floating)
 		floating = &Node{Kind: ElementKind, Data: "\\par"}
 	}
@@ -116,7 +358,13 @@ func (p *Parser) vertical(stop func(any, error) bool) (children []*Node, last an
 	// add whatever is hanging in floating paragraph before return
 	defer flush()
 
+	progress := p.progress()
+
 	for {
+		if err := progress(); err != nil {
+			return nil, nil, err
+		}
+
 		t, err := p.tokens.Token()
 		if stop(t, err) {
 			return children, t, nil
@@ -126,12 +374,15 @@ func (p *Parser) vertical(stop func(any, error) bool) (children []*Node, last an
 			return nil, nil, err
 		}
 
+		start := p.tokens.Start()
+
 		node, inline, err := p.parse(t)
 		if err != nil {
-			if p.strict {
+			if p.strict || errors.Is(err, errExpansionLimit) {
 				return nil, nil, err
 			}
 
+			p.recover(err)
 			continue
 		}
 
@@ -139,6 +390,8 @@ func (p *Parser) vertical(stop func(any, error) bool) (children []*Node, last an
 			continue
 		}
 
+		p.span(node, start)
+
 		if !inline {
 			flush()
 			children = append(children, node)
@@ -159,6 +412,7 @@ func (p *Parser) vertical(stop func(any, error) bool) (children []*Node, last an
 		// merge consequent text nodes together
 		if node.Kind == TextKind && len(floating.Children) > 0 && floating.Children[len(floating.Children)-1].Kind == TextKind {
 			floating.Children[len(floating.Children)-1].Data += node.Data
+			floating.Children[len(floating.Children)-1].Span.End = node.Span.End
 			continue
 		}
 
@@ -166,11 +420,25 @@ func (p *Parser) vertical(stop func(any, error) bool) (children []*Node, last an
 	}
 }
 
+// span records range in the source node was parsed from, it starts with the first token of the node and ends at the
+// current position. Nodes made by expanding custom environments don't have a place in the source and are not recorded.
+func (p *Parser) span(node *Node, start int64) {
+	if !p.sourceMap || p.expansions > 0 || node.Span != (Span{}) {
+		return
+	}
+
+	node.Span = Span{Start: int(start), End: int(p.tokens.Offset())}
+}
+
 func (p *Parser) parse(t any) (*Node, bool, error) {
 	switch token := t.(type) {
 	case Text:
 		return &Node{Kind: TextKind, Data: string(token)}, true, nil
 	case Symbol:
+		if token == "~" && p.literalTie {
+			return &Node{Kind: TextKind, Data: string(token)}, true, nil
+		}
+
 		return &Node{Kind: TextKind, Data: symbol(string(token))}, true, nil
 	case Command:
 		return p.command(token)
@@ -182,18 +450,21 @@ func (p *Parser) parse(t any) (*Node, bool, error) {
 		return &Node{Kind: TextKind, Data: "]"}, true, nil
 	case EnvironmentStart:
 		return p.environment(token)
+	case EnvironmentEnd:
+		// \\end without matching \\begin, environments consume their own \\end, so any \\end which reaches this point is stray
+		return nil, false, fmt.Errorf("unexpected \\end{%s} without matching \\begin", token.Name)
 	case ParameterStart:
 		// a bit of guessing here, this is hanging group it may enclose block or inline elements
 		// we parse it as vertical layout and then try to figure it out
-		children, _, err := p.vertical(func(a any, err error) bool {
-			_, ok := a.(ParameterEnd)
-			return err == nil && ok
-		})
-
+		children, last, err := p.vertical(p.groupEnd)
 		if err != nil {
 			return nil, false, err
 		}
 
+		if err := p.closeGroup(last); err != nil {
+			return nil, false, err
+		}
+
 		// empty group
 		if len(children) == 0 {
 			return &Node{Kind: TextKind}, true, nil
@@ -225,19 +496,51 @@ func (p *Parser) parse(t any) (*Node, bool, error) {
 }
 
 func (p *Parser) command(c Command) (*Node, bool, error) {
+	// aliases made by \\let are dispatched as the commands they refer to
+	if target, ok := p.aliases[c]; ok {
+		c = target
+	}
+
 	switch c {
 	case "\\symbol":
 		return p.symbol(c)
-	case "\\par", "\\\\", "\\\\*", "\\newline", "\\InputFile", "\\InputData", "\\OutputFile", "\\Note", "\\Scoring", "\\Interaction", "\\Example", "\\Examples":
+	case "\\\\", "\\\\*", "\\newline":
+		node := &Node{Kind: ElementKind, Data: string(c)}
+
+		// whitespace after line break is kept if it's not a single new line, so source can be rendered back as is
+		if space := p.tokens.space; c != "\\newline" && space != "\n" {
+			node.Parameters = map[string]string{"space": space}
+		}
+
+		// in verses line breaks are significant and stay inside paragraph
+		return node, p.verses > 0, nil
+	case "\\par", "\\InputFile", "\\InputData", "\\OutputFile", "\\Note", "\\Scoring", "\\Interaction", "\\Example", "\\Examples":
+		return &Node{Kind: ElementKind, Data: string(c)}, false, nil
+	case "\\appendix":
 		return &Node{Kind: ElementKind, Data: string(c)}, false, nil
 	case "\\dots", "\\ldots", "\\cdots", "\\vdots", "\\ddots", "\\hskip", "\\vskip":
 		return &Node{Kind: ElementKind, Data: string(c)}, true, nil
-	case "\\underline", "\\emph", "\\sout", "\\textmd", "\\textbf", "\\textup", "\\textit", "\\textsl", "\\textsc", "\\textsf", "\\textrm", "\\bf", "\\it", "\\t", "\\tt", "\\texttt", "\\tiny", "\\scriptsize", "\\small", "\\normalsize", "\\large", "\\Large", "\\LARGE", "\\huge", "\\Huge", "\\bfseries", "\\itshape":
+	case "\\slash":
+		// slash which allows line break after it, it's kept as element, so HTML can mark the break opportunity
+		return &Node{Kind: ElementKind, Data: string(c)}, true, nil
+	case "\\underline", "\\emph", "\\sout", "\\textmd", "\\textbf", "\\textup", "\\textit", "\\textsl", "\\textsc", "\\textsf", "\\textrm", "\\bf", "\\it", "\\t", "\\tt", "\\texttt", "\\tiny", "\\scriptsize", "\\small", "\\normalsize", "\\large", "\\Large", "\\LARGE", "\\huge", "\\Huge", "\\bfseries", "\\itshape", "\\st", "\\ul", "\\textsuperscript", "\\textsubscript":
+		return p.format(c)
+	case "\\phantom", "\\hphantom", "\\vphantom":
 		return p.format(c)
-	case "\\title", "\\chapter", "\\section", "\\subsection", "\\subsubsection", "\\subsubsubsection", "\\caption":
+	case "\\hl":
+		return p.highlight(c)
+	case "\\showln":
+		return p.showln(c)
+	case "\\title", "\\chapter", "\\section", "\\subsection", "\\subsubsection", "\\subsubsubsection":
 		return p.format(c)
+	case "\\caption":
+		return p.caption(c)
 	case "\\heading":
 		return p.heading(c)
+	case "\\phantomsection":
+		return p.phantomsection(c)
+	case "\\addcontentsline":
+		return p.addcontentsline(c)
 	case "\\includegraphics":
 		return p.graphics(c)
 	case "\\includemedia":
@@ -246,27 +549,84 @@ func (p *Parser) command(c Command) (*Node, bool, error) {
 		return p.url(c)
 	case "\\href":
 		return p.href(c)
+	case "\\texorpdfstring":
+		return p.texorpdfstring(c)
 	case "\\def":
 		return p.def(c)
+	case "\\newcommand", "\\renewcommand", "\\providecommand":
+		return p.newCommand(c)
+	case "\\newenvironment", "\\renewenvironment":
+		return p.newEnvironment(c)
+	case "\\let":
+		return p.let(c)
+	case "\\expandafter":
+		// changing order of expansion is not supported, command is ignored leaving following tokens intact
+		return nil, false, nil
+	case "\\string":
+		return p.string(c)
+	case "\\csname":
+		return p.csname(c)
+	case "\\else":
+		return p.conditionalElse(c)
+	case "\\fi":
+		return p.conditionalEnd(c)
+	case "\\relax", "\\ignorespaces":
+		// commands don't produce any output, spaces following \\ignorespaces are skipped by the tokenizer as after
+		// any other named command
+		return &Node{Kind: ElementKind, Data: string(c)}, true, nil
+	case "\\newcolumntype":
+		return p.newColumnType(c)
+	case "\\lstset":
+		return p.lstset(c)
+	case "\\verbatiminput":
+		return p.verbatimInput(c)
+	case "\\newcounter", "\\setcounter", "\\addtocounter", "\\stepcounter", "\\refstepcounter":
+		return p.counter(c)
+	case "\\arabic", "\\roman", "\\Roman", "\\alph", "\\Alph":
+		return p.counterValue(c)
+	case "\\documentclass", "\\usepackage":
+		return p.declaration(c)
 	case "\\epigraph":
 		return p.epigraph(c)
 	case "\\vspace":
 		return p.vspace(c)
 	case "\\hspace":
 		return p.hspace(c)
+	case "\\raisebox":
+		return p.raisebox(c)
 	case "\\exmp":
 		return p.exmp(c)
 	case "\\exmpfile":
 		return p.exmpfile(c)
+	case "\\-":
+		if p.softHyphens {
+			return &Node{Kind: TextKind, Data: string([]rune{0x00AD})}, true, nil
+		}
+
+		return nil, true, nil
 	case "\\multicolumn", "\\cline":
 		return nil, false, nil
 	case "\\user":
 		return p.user(c)
+	case "\\cite":
+		return p.cite(c)
+	case "\\label", "\\autoref", "\\nameref":
+		return p.label(c)
+	case "\\hyperref":
+		return p.hyperref(c)
 	default:
+		if isConditional(c) {
+			return p.conditional(c)
+		}
+
 		if v, ok := p.defs[string(c)]; ok {
 			return &Node{Kind: TextKind, Data: v}, true, nil
 		}
 
+		if m, ok := p.macros[string(c)]; ok {
+			return p.macro(c, m)
+		}
+
 		if v, ok := replacements[string(c)]; ok {
 			return &Node{Kind: TextKind, Data: v}, true, nil
 		}
@@ -293,14 +653,25 @@ func (p *Parser) verbatim(v Verbatim) (*Node, bool, error) {
 }
 
 func (p *Parser) environment(e EnvironmentStart) (*Node, bool, error) {
+	if env, ok := p.environments[e.Name]; ok {
+		return p.customEnvironment(e, env)
+	}
+
 	switch e.Name {
-	case "center", "example", "figure":
+	case "":
+		// \\begin{} is skipped, the content is parsed as if there was no environment
+		return nil, false, errors.New("environment name is expected")
+	case "center", "flushleft", "flushright", "example", "figure":
 		return p.division(e)
+	case "theorem", "lemma", "proof", "definition", "corollary", "remark":
+		return p.theorem(e)
 	case "itemize", "enumerate":
 		return p.list(e)
 	case "tabs":
 		return p.tabs(e)
-	case "tabular":
+	case "thebibliography":
+		return p.bibliography(e)
+	case "tabular", "tabular*", "tabularx":
 		return p.tabular(e)
 	case "problem":
 		return p.problem(e)
@@ -308,11 +679,28 @@ func (p *Parser) environment(e EnvironmentStart) (*Node, bool, error) {
 		return p.tutorial(e)
 	case "wrapfigure":
 		return p.wrapfigure(e)
+	case "minipage", "subfigure":
+		return p.minipage(e)
+	case "verse":
+		p.verses++
+		defer func() { p.verses-- }()
+
+		return p.division(e)
+	case "multicols":
+		return p.multicols(e)
+	case "array", "cases", "matrix", "pmatrix", "bmatrix", "Bmatrix", "vmatrix", "Vmatrix", "smallmatrix":
+		return p.mathEnvironment(e)
+	case "math", "displaymath":
+		return p.formulaEnvironment(e)
+	case "equation", "equation*", "align", "align*":
+		return p.equationEnvironment(e)
 	case "comment":
 		_, _, err := p.verbatimEnvironment(e)
 		return nil, false, err
 	case "lstlisting":
 		return p.lstListingEnvironment(e)
+	case "minted":
+		return p.mintedEnvironment(e)
 	case "verbatim":
 		return p.verbatimEnvironment(e)
 	default:
@@ -320,6 +708,162 @@ func (p *Parser) environment(e EnvironmentStart) (*Node, bool, error) {
 	}
 }
 
+// string reads \\string command, it produces the following token as text, for example command name
+func (p *Parser) string(c Command) (*Node, bool, error) {
+	t, err := p.tokens.Token()
+	if err != nil {
+		return nil, false, fmt.Errorf("%v must be followed by a token: %w", c, err)
+	}
+
+	switch token := t.(type) {
+	case Command:
+		return &Node{Kind: TextKind, Data: string(token)}, true, nil
+	case Text:
+		return &Node{Kind: TextKind, Data: string(token)}, true, nil
+	case Symbol:
+		return &Node{Kind: TextKind, Data: string(token)}, true, nil
+	case ParameterStart:
+		return &Node{Kind: TextKind, Data: "{"}, true, nil
+	case ParameterEnd:
+		return &Node{Kind: TextKind, Data: "}"}, true, nil
+	case OptionalStart:
+		return &Node{Kind: TextKind, Data: "["}, true, nil
+	case OptionalEnd:
+		return &Node{Kind: TextKind, Data: "]"}, true, nil
+	default:
+		// environments and verbatim are left to be parsed as usual
+		if err := p.tokens.Unread(); err != nil {
+			return nil, false, err
+		}
+
+		return nil, false, nil
+	}
+}
+
+// csname reads \\csname name\\endcsname and parses it as \\name command, so macros defined by \\def resolve
+func (p *Parser) csname(c Command) (*Node, bool, error) {
+	name := ""
+
+	for {
+		t, err := p.tokens.Token()
+		if err != nil {
+			return nil, false, fmt.Errorf("%v must be closed by \\endcsname: %w", c, err)
+		}
+
+		switch token := t.(type) {
+		case Text:
+			name += string(token)
+		case Symbol:
+			name += string(token)
+		case Command:
+			if token != "\\endcsname" {
+				return nil, false, fmt.Errorf("%v name must be a text, got %v", c, token)
+			}
+
+			return p.command(Command("\\" + name))
+		default:
+			return nil, false, fmt.Errorf("%v name must be a text, got %T", c, token)
+		}
+	}
+}
+
+// isConditional checks if command is a TeX conditional (\\iffalse, \\ifdefined, \\ifx etc.)
+func isConditional(c Command) bool {
+	return strings.HasPrefix(string(c), "\\if") && c != "\\ifthenelse"
+}
+
+// conditional reads TeX conditional: \\iftrue, \\iffalse and \\ifdefined\\command are evaluated, other
+// conditionals can't be evaluated, so their first branch is taken. If condition is false, content up to matching
+// \\else or \\fi is skipped.
+func (p *Parser) conditional(c Command) (*Node, bool, error) {
+	value := true
+
+	switch c {
+	case "\\iffalse":
+		value = false
+	case "\\ifdefined":
+		t, err := p.tokens.Token()
+		if err != nil {
+			return nil, false, fmt.Errorf("%v must be followed by a command: %w", c, err)
+		}
+
+		name, ok := t.(Command)
+		if !ok {
+			return nil, false, fmt.Errorf("%v must be followed by a command, got %T", c, t)
+		}
+
+		_, defined := p.defs[string(name)]
+		_, replaced := replacements[string(name)]
+		_, aliased := p.aliases[name]
+		_, macro := p.macros[string(name)]
+		value = defined || replaced || aliased || macro
+	}
+
+	if value {
+		p.conditions++
+		return nil, false, nil
+	}
+
+	end, err := p.skipConditional(true)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if end == "\\else" {
+		p.conditions++
+	}
+
+	return nil, false, nil
+}
+
+// conditionalElse reads \\else, it ends taken branch of a conditional, so the rest up to \\fi is skipped
+func (p *Parser) conditionalElse(c Command) (*Node, bool, error) {
+	if p.conditions == 0 {
+		return nil, false, fmt.Errorf("unexpected %v without matching \\if", c)
+	}
+
+	p.conditions--
+
+	_, err := p.skipConditional(false)
+	return nil, false, err
+}
+
+// conditionalEnd reads \\fi which ends a conditional
+func (p *Parser) conditionalEnd(c Command) (*Node, bool, error) {
+	if p.conditions == 0 {
+		return nil, false, fmt.Errorf("unexpected %v without matching \\if", c)
+	}
+
+	p.conditions--
+
+	return nil, false, nil
+}
+
+// skipConditional skips tokens up to \\fi (or \\else, if else is true) which belongs to the current conditional,
+// nested conditionals are skipped as a whole. It returns command skipping stopped at.
+func (p *Parser) skipConditional(stopAtElse bool) (Command, error) {
+	depth := 0
+
+	for {
+		t, err := p.tokens.Token()
+		if err != nil {
+			return "", fmt.Errorf("conditional must be closed by \\fi: %w", err)
+		}
+
+		c, ok := t.(Command)
+
+		switch {
+		case !ok:
+		case isConditional(c):
+			depth++
+		case c == "\\fi" && depth > 0:
+			depth--
+		case c == "\\fi" || (c == "\\else" && depth == 0 && stopAtElse):
+			return c, nil
+		}
+	}
+}
+
 // symbol is a \\symbol command
 func (p *Parser) symbol(c Command) (*Node, bool, error) {
 	val, _, err := p.parameterVerbatim()
@@ -345,6 +889,37 @@ func (p *Parser) format(c Command) (*Node, bool, error) {
 	return &Node{Kind: ElementKind, Data: string(c), Children: children}, true, nil
 }
 
+// highlight reads \\hl command with an optional color: \\hl[yellow]{...}
+func (p *Parser) highlight(c Command) (*Node, bool, error) {
+	var params map[string]string
+
+	color, _, err := p.optionVerbatim()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if color != "" {
+		params = map[string]string{"color": color}
+	}
+
+	children, _, err := p.parameter()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &Node{Kind: ElementKind, Data: string(c), Children: children, Parameters: params}, true, nil
+}
+
+// showln reads \\showln command which marks highlighted lines in examples: \\showln{...}
+func (p *Parser) showln(c Command) (*Node, bool, error) {
+	children, _, err := p.parameter()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &Node{Kind: ElementKind, Data: string(c), Children: children, Parameters: map[string]string{"highlight": "true"}}, true, nil
+}
+
 // heading is a command with a single optional parameter \heading[1]{...}
 func (p *Parser) heading(c Command) (*Node, bool, error) {
 	attr := map[string]string{"level": "1"}
@@ -362,36 +937,83 @@ func (p *Parser) heading(c Command) (*Node, bool, error) {
 	return &Node{Kind: ElementKind, Data: string(c), Children: children, Parameters: attr}, true, nil
 }
 
-// graphics reads \\includegraphics command
-func (p *Parser) graphics(c Command) (*Node, bool, error) {
-	params := map[string]string{}
-
-	options, ok, err := p.optionVerbatim()
+// caption reads \\caption[short]{long} command, optional short caption is used in the list of figures
+func (p *Parser) caption(c Command) (*Node, bool, error) {
+	short, ok, err := p.optionVerbatim()
 	if err != nil {
-		return nil, false, err
-	}
-
-	if ok {
-		params["options"] = options
+		return nil, false, fmt.Errorf("invalid caption short parameter: %w", err)
 	}
 
-	src, ok, err := p.parameterVerbatim()
+	children, _, err := p.parameter()
 	if err != nil {
 		return nil, false, err
 	}
 
+	node := &Node{Kind: ElementKind, Data: string(c), Children: children}
 	if ok {
-		params["src"] = src
+		node.Parameters = map[string]string{"short": short}
 	}
 
-	return &Node{Kind: ElementKind, Data: string(c), Parameters: params}, false, nil
+	return node, true, nil
 }
 
-// media reads \\includemedia command
-func (p *Parser) media(c Command) (*Node, bool, error) {
-	params := map[string]string{}
+// phantomsection reads \\phantomsection command, it creates an anchor which can be referenced from table of contents
+func (p *Parser) phantomsection(c Command) (*Node, bool, error) {
+	p.phantoms++
 
-	options, ok, err := p.optionVerbatim()
+	return &Node{Kind: ElementKind, Data: string(c), Parameters: map[string]string{"anchor": fmt.Sprintf("phantomsection-%d", p.phantoms)}}, true, nil
+}
+
+// addcontentsline reads \\addcontentsline{file}{level}{title} command
+func (p *Parser) addcontentsline(c Command) (*Node, bool, error) {
+	file, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid addcontentsline file parameter: %w", err)
+	}
+
+	level, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid addcontentsline level parameter: %w", err)
+	}
+
+	title, _, err := p.parameter()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid addcontentsline title parameter: %w", err)
+	}
+
+	return &Node{Kind: ElementKind, Data: string(c), Parameters: map[string]string{"file": file, "level": level}, Children: title}, true, nil
+}
+
+// graphics reads \\includegraphics command
+func (p *Parser) graphics(c Command) (*Node, bool, error) {
+	params := map[string]string{}
+
+	options, ok, err := p.optionVerbatim()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if ok {
+		params["options"] = options
+	}
+
+	src, ok, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if ok {
+		params["src"] = src
+	}
+
+	return &Node{Kind: ElementKind, Data: string(c), Parameters: params}, false, nil
+}
+
+// media reads \\includemedia command
+func (p *Parser) media(c Command) (*Node, bool, error) {
+	params := map[string]string{}
+
+	options, ok, err := p.optionVerbatim()
 	if err != nil {
 		return nil, false, err
 	}
@@ -414,7 +1036,7 @@ func (p *Parser) media(c Command) (*Node, bool, error) {
 
 // url reads \\url command
 func (p *Parser) url(c Command) (*Node, bool, error) {
-	href, _, err := p.parameterVerbatim()
+	href, _, err := p.parameterURL()
 	if err != nil {
 		return nil, false, err
 	}
@@ -434,7 +1056,12 @@ func (p *Parser) user(c Command) (*Node, bool, error) {
 
 // href reads \\href command
 func (p *Parser) href(c Command) (*Node, bool, error) {
-	href, _, err := p.parameterVerbatim()
+	options, hasOptions, err := p.optionVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid href options parameter: %w", err)
+	}
+
+	href, _, err := p.parameterURL()
 	if err != nil {
 		return nil, false, err
 	}
@@ -444,7 +1071,80 @@ func (p *Parser) href(c Command) (*Node, bool, error) {
 		return nil, false, err
 	}
 
-	return &Node{Kind: ElementKind, Data: string(c), Parameters: map[string]string{"href": href}, Children: children}, true, nil
+	params := map[string]string{"href": href}
+	if hasOptions {
+		params["options"] = options
+	}
+
+	return &Node{Kind: ElementKind, Data: string(c), Parameters: params, Children: children}, true, nil
+}
+
+// texorpdfstring reads \\texorpdfstring{content}{plain} command, content is kept as children and plain text
+// alternative (used in bookmarks and table of contents) as "pdfstring" parameter
+func (p *Parser) texorpdfstring(c Command) (*Node, bool, error) {
+	children, _, err := p.parameter()
+	if err != nil {
+		return nil, false, err
+	}
+
+	plain, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid texorpdfstring plain parameter: %w", err)
+	}
+
+	return &Node{Kind: ElementKind, Data: string(c), Parameters: map[string]string{"pdfstring": plain}, Children: children}, true, nil
+}
+
+// cite reads \\cite[note]{key1,key2} command
+func (p *Parser) cite(c Command) (*Node, bool, error) {
+	note, _, err := p.optionVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid cite note parameter: %w", err)
+	}
+
+	list, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid cite keys parameter: %w", err)
+	}
+
+	var keys []string
+	for _, key := range strings.Split(list, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	params := map[string]string{"keys": strings.Join(keys, ",")}
+	if note != "" {
+		params["note"] = note
+	}
+
+	return &Node{Kind: ElementKind, Data: string(c), Parameters: params}, true, nil
+}
+
+// label reads commands which take a single label as a parameter: \\label, \\autoref and \\nameref
+func (p *Parser) label(c Command) (*Node, bool, error) {
+	label, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %v label parameter: %w", c, err)
+	}
+
+	return &Node{Kind: ElementKind, Data: string(c), Parameters: map[string]string{"label": label}}, true, nil
+}
+
+// hyperref reads \\hyperref[label]{text} command
+func (p *Parser) hyperref(c Command) (*Node, bool, error) {
+	label, _, err := p.optionVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid hyperref label parameter: %w", err)
+	}
+
+	children, _, err := p.parameter()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid hyperref text parameter: %w", err)
+	}
+
+	return &Node{Kind: ElementKind, Data: string(c), Parameters: map[string]string{"label": label}, Children: children}, true, nil
 }
 
 // def reads \\def command
@@ -470,6 +1170,436 @@ func (p *Parser) def(c Command) (*Node, bool, error) {
 	return nil, false, nil
 }
 
+// newCommand reads command definition: \\newcommand{\\name}[args][default]{value}, the name can be given without
+// braces. Like with \\def, value is substituted as is, arguments are substituted into it the same way as into custom
+// environments. \\newcommand fails if command is already defined, \\renewcommand overrides existing definition and
+// \\providecommand is ignored if command is defined.
+func (p *Parser) newCommand(c Command) (*Node, bool, error) {
+	key, ok, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %v name parameter: %w", c, err)
+	}
+
+	// name without braces, like \\newcommand\\name{value}
+	if !ok {
+		token, err := p.tokens.Token()
+		if err != nil {
+			return nil, false, fmt.Errorf("unable to read %v name: %w", c, err)
+		}
+
+		name, _ := token.(Command)
+		key = string(name)
+	}
+
+	key = strings.TrimSpace(key)
+	if !identifier.MatchString(key) {
+		return nil, false, fmt.Errorf("%v must be followed by identifier, for example: \\xyz, got %#v", c, key)
+	}
+
+	m := macro{}
+	if n, ok, err := p.optionString(); err != nil {
+		return nil, false, fmt.Errorf("invalid %v arguments parameter: %w", c, err)
+	} else if ok {
+		if m.args, err = strconv.Atoi(strings.TrimSpace(n)); err != nil || m.args < 0 || m.args > 9 {
+			return nil, false, fmt.Errorf("number of command arguments must be an integer between 0 and 9, got %#v", n)
+		}
+	}
+
+	if v, ok, err := p.optionVerbatim(); err != nil {
+		return nil, false, fmt.Errorf("invalid %v default parameter: %w", c, err)
+	} else if ok {
+		m.optional = &v
+	}
+
+	val, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid value in %v: %w", c, err)
+	}
+
+	_, defined := p.defs[key]
+	if _, ok := replacements[key]; ok {
+		defined = true
+	}
+
+	if _, ok := p.macros[key]; ok {
+		defined = true
+	}
+
+	switch {
+	case c == "\\newcommand" && defined:
+		return nil, false, fmt.Errorf("command %v is already defined", key)
+	case c == "\\providecommand" && defined:
+		return nil, false, nil
+	}
+
+	if m.args == 0 {
+		p.Define(key, val)
+		return nil, false, nil
+	}
+
+	m.body = val
+	p.macros[key] = m
+	delete(p.defs, key)
+	delete(p.aliases, Command(key))
+
+	return nil, false, nil
+}
+
+// macro is a command with arguments defined by \\newcommand
+type macro struct {
+	args     int     // number of arguments command takes
+	optional *string // default value of the first argument, if it's optional
+	body     string  // value of the command, arguments are referenced as #1, #2 etc.
+}
+
+// macro substitutes arguments of the command defined by \\newcommand into its value
+func (p *Parser) macro(c Command, m macro) (*Node, bool, error) {
+	value, err := p.arguments(m.body, m.args, m.optional)
+	if err != nil {
+		return nil, false, fmt.Errorf("command %v: %w", c, err)
+	}
+
+	return &Node{Kind: TextKind, Data: value}, true, nil
+}
+
+// arguments reads arguments of custom command or environment and substitutes them into code instead of #1, #2 etc.
+// If optional is set, the first argument is optional and optional is its default value.
+func (p *Parser) arguments(code string, args int, optional *string) (string, error) {
+	for arg := 1; arg <= args; arg++ {
+		var value string
+		var err error
+
+		if arg == 1 && optional != nil {
+			var ok bool
+			if value, ok, err = p.optionVerbatim(); !ok && err == nil {
+				value = *optional
+			}
+		} else {
+			value, _, err = p.parameterBalanced()
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("invalid argument #%d: %w", arg, err)
+		}
+
+		code = strings.ReplaceAll(code, "#"+strconv.Itoa(arg), value)
+	}
+
+	return code, nil
+}
+
+// environment is a custom environment defined by \\newenvironment
+type environment struct {
+	args     int     // number of arguments environment takes
+	optional *string // default value of the first argument, if it's optional
+	begin    string  // code inserted at \\begin, arguments are referenced as #1, #2 etc.
+	end      string  // code inserted at \\end
+}
+
+// newEnvironment reads environment definition: \\newenvironment{name}[args][default]{begin}{end}. By default,
+// definition is registered and custom environment is expanded when it's used. With WithKeepEnvironmentDefinitions
+// option, definition is returned as a node.
+func (p *Parser) newEnvironment(c Command) (*Node, bool, error) {
+	name, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %v name parameter: %w", c, err)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, false, fmt.Errorf("%v name must not be empty", c)
+	}
+
+	params := map[string]string{"name": name}
+	env := environment{}
+
+	if n, ok, err := p.optionString(); err != nil {
+		return nil, false, fmt.Errorf("invalid %v arguments parameter: %w", c, err)
+	} else if ok {
+		if env.args, err = strconv.Atoi(strings.TrimSpace(n)); err != nil || env.args < 0 || env.args > 9 {
+			return nil, false, fmt.Errorf("number of environment arguments must be an integer between 0 and 9, got %#v", n)
+		}
+
+		params["args"] = strings.TrimSpace(n)
+	}
+
+	if v, ok, err := p.optionVerbatim(); err != nil {
+		return nil, false, fmt.Errorf("invalid %v default parameter: %w", c, err)
+	} else if ok {
+		env.optional = &v
+		params["default"] = v
+	}
+
+	if env.begin, _, err = p.parameterBalanced(); err != nil {
+		return nil, false, fmt.Errorf("invalid %v begin parameter: %w", c, err)
+	}
+
+	if env.end, _, err = p.parameterBalanced(); err != nil {
+		return nil, false, fmt.Errorf("invalid %v end parameter: %w", c, err)
+	}
+
+	if p.keepEnvs {
+		params["begin"], params["end"] = env.begin, env.end
+		return &Node{Kind: ElementKind, Data: string(c), Parameters: params}, false, nil
+	}
+
+	if _, defined := p.environments[name]; defined && c == "\\newenvironment" {
+		return nil, false, fmt.Errorf("environment %v is already defined", name)
+	}
+
+	p.environments[name] = env
+
+	return nil, false, nil
+}
+
+// customEnvironment expands environment defined by \\newenvironment: arguments are substituted into begin code,
+// and content of the environment is parsed together with begin and end code. Result is a group of parsed nodes.
+func (p *Parser) customEnvironment(e EnvironmentStart, env environment) (*Node, bool, error) {
+	if p.expansions >= maxEnvironmentExpansions {
+		return nil, false, fmt.Errorf("environment %v is nested too deep", e.Name)
+	}
+
+	begin, err := p.arguments(env.begin, env.args, env.optional)
+	if err != nil {
+		return nil, false, fmt.Errorf("environment %v: %w", e.Name, err)
+	}
+
+	// content is read as is up to matching \\end, nested environments with the same name are kept
+	opening, closing := "\\begin{"+e.Name+"}", "\\end{"+e.Name+"}"
+	content := strings.Builder{}
+	depth := 0
+
+	_, err = p.tokens.Verbatim(func(r rune, err error) bool {
+		if err != nil {
+			return true
+		}
+
+		content.WriteRune(r)
+
+		// String does not copy the buffer, only the tail of the content is compared
+		switch tail := content.String(); {
+		case strings.HasSuffix(tail, opening):
+			depth++
+		case strings.HasSuffix(tail, closing) && depth > 0:
+			depth--
+		case strings.HasSuffix(tail, closing):
+			return true
+		}
+
+		return false
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	code := begin + strings.TrimSuffix(content.String(), closing) + env.end
+
+	// each expansion may contain several custom environments, total size of expanded code is limited, so nested
+	// environments can't make the parser expand exponential amount of code
+	if p.expanded += len(code); p.expanded > maxExpandedBytes {
+		return nil, false, fmt.Errorf("environment %v: %w", e.Name, errExpansionLimit)
+	}
+
+	if p.expansions == 0 {
+		p.origin = p.tokens.Start()
+	}
+
+	// code is parsed with the same parser state (definitions, counters etc.), only the input is replaced
+	tokens := p.tokens
+	p.tokens = NewTokenizer(strings.NewReader(code))
+	p.tokens.lenient = tokens.lenient
+	p.expansions++
+
+	defer func() {
+		p.tokens = tokens
+		p.expansions--
+	}()
+
+	children, _, err := p.vertical(func(a any, err error) bool {
+		return err == io.EOF
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &Node{Kind: ElementKind, Data: "{}", Children: children}, false, nil
+}
+
+// let reads assignment \\let\\a\\b (or \\let\\a=\\b), which makes \\a an alias of \\b. Value of commands defined with
+// \\def (or similar) and symbols with known replacements is copied, other commands (like \\textbf) are aliased, so \\a
+// is parsed as \\b.
+func (p *Parser) let(c Command) (*Node, bool, error) {
+	token, err := p.tokens.Token()
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to read %v identifier: %w", c, err)
+	}
+
+	key, ok := token.(Command)
+	if !ok || !identifier.MatchString(string(key)) {
+		return nil, false, fmt.Errorf("%v must be followed by identifier, for example: \\xyz", c)
+	}
+
+	token, err = p.tokens.Token()
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to read %v value: %w", c, err)
+	}
+
+	// optional equal sign between identifier and value
+	if t, ok := token.(Text); ok && strings.TrimSpace(string(t)) == "=" {
+		if token, err = p.tokens.Token(); err != nil {
+			return nil, false, fmt.Errorf("unable to read %v value: %w", c, err)
+		}
+	}
+
+	value, ok := token.(Command)
+	if !ok {
+		return nil, false, fmt.Errorf("%v value must be a command", c)
+	}
+
+	if v, ok := p.defs[string(value)]; ok {
+		p.Define(string(key), v)
+		return nil, false, nil
+	}
+
+	if v, ok := replacements[string(value)]; ok {
+		p.Define(string(key), v)
+		return nil, false, nil
+	}
+
+	if target, ok := p.aliases[value]; ok {
+		value = target
+	}
+
+	delete(p.defs, string(key))
+	p.aliases[key] = value
+
+	return nil, false, nil
+}
+
+// newColumnType reads definition of custom column type: \\newcolumntype{C}[args]{definition}, custom column types
+// are expanded in colspec of tables defined after
+func (p *Parser) newColumnType(c Command) (*Node, bool, error) {
+	name, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %v name parameter: %w", c, err)
+	}
+
+	name = strings.TrimSpace(name)
+	if len(name) != 1 || !isLetter(rune(name[0])) {
+		return nil, false, fmt.Errorf("column type must be a single letter, got %#v", name)
+	}
+
+	args := 0
+	if n, ok, err := p.optionString(); err != nil {
+		return nil, false, fmt.Errorf("invalid %v arguments parameter: %w", c, err)
+	} else if ok {
+		if args, err = strconv.Atoi(strings.TrimSpace(n)); err != nil || args < 0 || args > 9 {
+			return nil, false, fmt.Errorf("number of column type arguments must be an integer between 0 and 9, got %#v", n)
+		}
+	}
+
+	definition, _, err := p.parameterBalanced()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %v definition parameter: %w", c, err)
+	}
+
+	p.columnTypes[name] = columnType{args: args, definition: definition}
+
+	return nil, false, nil
+}
+
+// counter reads commands defining and changing counters: \\newcounter{name}, \\setcounter{name}{value},
+// \\addtocounter{name}{value}, \\stepcounter{name} and \\refstepcounter{name}
+func (p *Parser) counter(c Command) (*Node, bool, error) {
+	name, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %v name parameter: %w", c, err)
+	}
+
+	name = strings.TrimSpace(name)
+
+	if c == "\\newcounter" {
+		// optional parent counter is not supported, but it has to be consumed
+		if _, _, err := p.optionVerbatim(); err != nil {
+			return nil, false, fmt.Errorf("invalid %v within parameter: %w", c, err)
+		}
+
+		if _, ok := p.counters[name]; ok {
+			return nil, false, fmt.Errorf("counter %#v is already defined", name)
+		}
+
+		p.counters[name] = 0
+		return nil, false, nil
+	}
+
+	if _, ok := p.counters[name]; !ok {
+		return nil, false, fmt.Errorf("counter %#v is not defined", name)
+	}
+
+	switch c {
+	case "\\setcounter", "\\addtocounter":
+		raw, _, err := p.parameterVerbatim()
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid %v value parameter: %w", c, err)
+		}
+
+		value, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid %v value parameter: %w", c, err)
+		}
+
+		if c == "\\addtocounter" {
+			value += p.counters[name]
+		}
+
+		p.counters[name] = value
+	default:
+		p.counters[name]++
+	}
+
+	return nil, false, nil
+}
+
+// counterValue reads commands printing counter value: \\arabic{name}, \\roman{name}, \\alph{name} etc.
+func (p *Parser) counterValue(c Command) (*Node, bool, error) {
+	name, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %v name parameter: %w", c, err)
+	}
+
+	value, ok := p.counters[strings.TrimSpace(name)]
+	if !ok {
+		return nil, false, fmt.Errorf("counter %#v is not defined", name)
+	}
+
+	return &Node{Kind: TextKind, Data: formatCounter(value, string(c)+"*")}, true, nil
+}
+
+// declaration reads preamble commands with optional options and a name, like \\usepackage[utf8]{inputenc}
+func (p *Parser) declaration(c Command) (*Node, bool, error) {
+	params := map[string]string{}
+
+	options, ok, err := p.optionVerbatim()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if ok {
+		params["options"] = options
+	}
+
+	name, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %v name parameter: %w", c, err)
+	}
+
+	params["name"] = name
+
+	return &Node{Kind: ElementKind, Data: string(c), Parameters: params}, false, nil
+}
+
 // epigraph reads \\epigraph command
 func (p *Parser) epigraph(c Command) (*Node, bool, error) {
 	text, _, err := p.parameter()
@@ -510,6 +1640,44 @@ func (p *Parser) hspace(c Command) (*Node, bool, error) {
 	return &Node{Kind: ElementKind, Data: string(c), Parameters: map[string]string{"width": width}}, false, nil
 }
 
+// raisebox reads \\raisebox command
+func (p *Parser) raisebox(c Command) (*Node, bool, error) {
+	lift, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid raisebox lift parameter: %w", err)
+	}
+
+	if _, _, err := Measure(lift); err != nil {
+		return nil, false, fmt.Errorf("invalid raisebox lift parameter: %w", err)
+	}
+
+	params := map[string]string{"lift": lift}
+
+	for _, key := range []string{"height", "depth"} {
+		val, ok, err := p.optionVerbatim()
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid raisebox %s parameter: %w", key, err)
+		}
+
+		if !ok {
+			break
+		}
+
+		if _, _, err := Measure(val); err != nil {
+			return nil, false, fmt.Errorf("invalid raisebox %s parameter: %w", key, err)
+		}
+
+		params[key] = val
+	}
+
+	children, _, err := p.parameter()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid raisebox content parameter: %w", err)
+	}
+
+	return &Node{Kind: ElementKind, Data: string(c), Parameters: params, Children: children}, true, nil
+}
+
 // exmp reads \\exmp command
 func (p *Parser) exmp(c Command) (*Node, bool, error) {
 	input, _, err := p.parameterVerbatim()
@@ -578,11 +1746,48 @@ func (p *Parser) division(e EnvironmentStart) (*Node, bool, error) {
 	return &Node{Kind: ElementKind, Data: e.Name, Children: children, Parameters: params}, false, nil
 }
 
+// theorem reads theorem-like environment (theorem, lemma, proof etc.) with optional name: \\begin{theorem}[name]
+func (p *Parser) theorem(e EnvironmentStart) (*Node, bool, error) {
+	node, ok, err := p.division(e)
+	if node == nil {
+		return node, ok, err
+	}
+
+	if opt, ok := node.Parameters["options"]; ok {
+		node.Parameters = map[string]string{"name": opt}
+	}
+
+	return node, ok, err
+}
+
 // list reads an environment with multiple items defined by \\item command
 func (p *Parser) list(e EnvironmentStart) (*Node, bool, error) {
 	var items []*Node
+	var params map[string]string
 	itimized := false
 
+	opt, _, err := p.optionVerbatim()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if opt != "" {
+		params = map[string]string{"options": opt}
+	}
+
+	// items of enumerate are numbered by the counter of its level, counter can be changed by \\setcounter
+	counter, next := "", 0
+	if e.Name == "enumerate" {
+		counter = enumerateCounter(p.enumerates)
+		p.counters[counter] = enumerateStart(opt, p.counters[counter]) - 1
+		next = p.counters[counter] + 1
+
+		p.enumerates++
+		defer func() { p.enumerates-- }()
+	}
+
+	var value int
+
 	for {
 		children, last, err := p.vertical(func(a any, err error) bool {
 			if err != nil {
@@ -605,7 +1810,13 @@ func (p *Parser) list(e EnvironmentStart) (*Node, bool, error) {
 		}
 
 		if itimized {
-			items = append(items, &Node{Kind: ElementKind, Data: "\\item", Children: children})
+			item := &Node{Kind: ElementKind, Data: "\\item", Children: children}
+			if counter != "" && value != next {
+				item.Parameters = map[string]string{"value": strconv.Itoa(value)}
+			}
+
+			items = append(items, item)
+			next = value + 1
 		}
 
 		// this skip content until we found first \\item
@@ -614,9 +1825,22 @@ func (p *Parser) list(e EnvironmentStart) (*Node, bool, error) {
 		if _, ok := last.(EnvironmentEnd); ok {
 			break
 		}
+
+		if counter != "" {
+			p.counters[counter]++
+			value = p.counters[counter]
+
+			if len(items) == 0 && value != 1 {
+				if params == nil {
+					params = map[string]string{}
+				}
+
+				params["start"] = strconv.Itoa(value)
+			}
+		}
 	}
 
-	return &Node{Kind: ElementKind, Data: e.Name, Children: items}, false, nil
+	return &Node{Kind: ElementKind, Data: e.Name, Parameters: params, Children: items}, false, nil
 }
 
 // tabs reads an environment with multiple items defined by \\item command
@@ -675,29 +1899,151 @@ func (p *Parser) tabs(e EnvironmentStart) (*Node, bool, error) {
 	return &Node{Kind: ElementKind, Data: e.Name, Children: items}, false, nil
 }
 
+// bibliography reads thebibliography environment with multiple items defined by \\bibitem[label]{key} command
+func (p *Parser) bibliography(e EnvironmentStart) (*Node, bool, error) {
+	widest, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to read thebibliography environment {widest} parameter: %w", err)
+	}
+
+	var items []*Node
+	itimized := false
+	attrs := map[string]string{}
+
+	for {
+		children, last, err := p.vertical(func(a any, err error) bool {
+			if err != nil {
+				return false
+			}
+
+			if n, ok := a.(EnvironmentEnd); ok {
+				return n.Name == e.Name
+			}
+
+			if c, ok := a.(Command); ok {
+				return string(c) == "\\bibitem"
+			}
+
+			return false
+		})
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if itimized {
+			items = append(items, &Node{Kind: ElementKind, Data: "\\bibitem", Children: children, Parameters: attrs})
+			attrs = map[string]string{}
+		}
+
+		// this skip content until we found first \\bibitem
+		if c, ok := last.(Command); ok && c == "\\bibitem" {
+			itimized = true
+
+			label, ok, err := p.optionVerbatim()
+			if err != nil {
+				return nil, false, err
+			}
+
+			if ok {
+				attrs["label"] = label
+			}
+
+			key, _, err := p.parameterVerbatim()
+			if err != nil {
+				return nil, false, err
+			}
+
+			attrs["key"] = key
+		}
+
+		if _, ok := last.(EnvironmentEnd); ok {
+			break
+		}
+	}
+
+	return &Node{Kind: ElementKind, Data: e.Name, Parameters: map[string]string{"widest": widest}, Children: items}, false, nil
+}
+
 // tabular reads tabular environment, where cells are separated by "&" and rows are separated by \\
 func (p *Parser) tabular(e EnvironmentStart) (*Node, bool, error) {
+	// tabular* and tabularx take target width before other parameters
+	width := ""
+	if e.Name != "tabular" {
+		var err error
+		if width, _, err = p.parameterVerbatim(); err != nil {
+			return nil, false, fmt.Errorf("unable to read %s environment {width} parameter: %w", e.Name, err)
+		}
+	}
+
 	pos, _, err := p.optionString()
 	if err != nil {
 		return nil, false, fmt.Errorf("unable to read tabular environment [pos] parameter: %w", err)
 	}
 
-	colspec, _, err := p.parameterString()
+	colspec, _, err := p.parameterBalanced()
 	if err != nil {
 		return nil, false, fmt.Errorf("unable to read tabular environment {colspec} parameter: %w", err)
 	}
 
+	// colspec is kept as written, so table renders back to the same source, expanded columns are kept separately
+	columns := expandColumnTypes(colspec, p.columnTypes)
+
+	rows, err := p.rows(e.Name, columns)
+	if err != nil {
+		return nil, false, err
+	}
+
+	params := map[string]string{"colspec": colspec}
+	if columns != colspec {
+		params["columns"] = columns
+	}
+
+	if pos != "" {
+		params["pos"] = pos
+	}
+
+	if width != "" {
+		params["width"] = width
+	}
+
+	return &Node{Kind: ElementKind, Parameters: params, Data: e.Name, Children: rows}, false, nil
+}
+
+// rows reads rows of tabular environment until \\end{name}, if name is empty rows are read until the end of input
+func (p *Parser) rows(name, colspec string) ([]*Node, error) {
+	p.tables++
+	defer func() { p.tables-- }()
+
 	var rows []*Node
 	hanging := &Node{Kind: ElementKind, Data: "\\row"}
 
-	addCell := func(nodes []*Node, params map[string]string) {
+	// start of the current cell in the source, each cell ends where the token which stopped it starts
+	start := p.tokens.Offset()
+
+	addCell := func(nodes []*Node, params map[string]string, end int64) {
 		if len(nodes) > 0 {
-			hanging.Children = append(hanging.Children, &Node{Kind: ElementKind, Data: "\\cell", Parameters: params, Children: nodes})
+			cell := &Node{Kind: ElementKind, Data: "\\cell", Parameters: params, Children: nodes}
+			if p.sourceMap {
+				cell.Span = Span{Start: int(start), End: int(end)}
+			}
+
+			hanging.Children = append(hanging.Children, cell)
 		}
 	}
 
+	columns := len(ColumnSpecs(colspec))
+
 	addHanging := func() {
 		if len(hanging.Children) > 0 {
+			if p.validate {
+				p.validateRow(hanging, columns)
+			}
+
+			if p.sourceMap {
+				hanging.Span = Span{Start: hanging.Children[0].Span.Start, End: hanging.Children[len(hanging.Children)-1].Span.End}
+			}
+
 			rows = append(rows, hanging)
 			hanging = &Node{Kind: ElementKind, Data: "\\row"}
 		}
@@ -706,11 +2052,11 @@ func (p *Parser) tabular(e EnvironmentStart) (*Node, bool, error) {
 	for {
 		children, last, err := p.vertical(func(a any, err error) bool {
 			if err != nil {
-				return false
+				return name == "" && err == io.EOF
 			}
 
 			if n, ok := a.(EnvironmentEnd); ok {
-				return n.Name == e.Name
+				return name != "" && n.Name == name
 			}
 
 			if n, ok := a.(Symbol); ok {
@@ -726,21 +2072,38 @@ func (p *Parser) tabular(e EnvironmentStart) (*Node, bool, error) {
 		})
 
 		if err != nil {
-			return nil, false, err
+			return nil, err
+		}
+
+		end := p.tokens.Start()
+		if last == nil { // end of input
+			end = p.tokens.Offset()
 		}
 
 		// depending on how we stopped reading,
 		if n, ok := last.(Symbol); ok && n == "&" {
 			// stopped by "&", add new cell
-			addCell(children, nil)
+			addCell(children, nil, end)
+			start = p.tokens.Offset()
 			continue
 		}
 
 		if c, ok := last.(Command); ok {
 			// stopped by newline, add new row
 			if isNewline(string(c)) {
-				addCell(children, nil)
+				addCell(children, nil, end)
+
+				// \\\\* is a row break which doesn't allow page break after the row
+				if c == "\\\\*" && len(hanging.Children) > 0 {
+					if hanging.Parameters == nil {
+						hanging.Parameters = map[string]string{}
+					}
+
+					hanging.Parameters["nobreak"] = "true"
+				}
+
 				addHanging()
+				start = p.tokens.Offset()
 				continue
 			}
 
@@ -748,26 +2111,28 @@ func (p *Parser) tabular(e EnvironmentStart) (*Node, bool, error) {
 			if string(c) == "\\multirow" {
 				num, _, err := p.parameterVerbatim()
 				if err != nil {
-					return nil, false, err
+					return nil, err
 				}
 
 				width, _, err := p.parameterVerbatim()
 				if err != nil {
-					return nil, false, err
+					return nil, err
 				}
 
 				text, _, err := p.parameter()
 				if err != nil {
-					return nil, false, err
+					return nil, err
 				}
 
-				addCell([]*Node{{Kind: ElementKind, Data: "\\par", Children: text}}, map[string]string{"rowspan": num, "width": width})
+				start = end
+				addCell([]*Node{{Kind: ElementKind, Data: "\\par", Children: text}}, map[string]string{"rowspan": num, "width": width}, p.tokens.Offset())
 
 				// try to eat next & so we don't create an empty column
 				if err := p.eatATab(); err != nil {
-					return nil, false, err
+					return nil, err
 				}
 
+				start = p.tokens.Offset()
 				continue
 			}
 
@@ -775,25 +2140,27 @@ func (p *Parser) tabular(e EnvironmentStart) (*Node, bool, error) {
 			if string(c) == "\\multicolumn" {
 				num, _, err := p.parameterVerbatim()
 				if err != nil {
-					return nil, false, err
+					return nil, err
 				}
 
 				align, _, err := p.parameterVerbatim()
 				if err != nil {
-					return nil, false, err
+					return nil, err
 				}
 
 				text, _, err := p.parameter()
 				if err != nil {
-					return nil, false, err
+					return nil, err
 				}
 
-				addCell([]*Node{{Kind: ElementKind, Data: "\\par", Children: text}}, map[string]string{"colspan": num, "align": align})
+				start = end
+				addCell([]*Node{{Kind: ElementKind, Data: "\\par", Children: text}}, map[string]string{"colspan": num, "align": align}, p.tokens.Offset())
 
 				if err := p.eatATab(); err != nil {
-					return nil, false, err
+					return nil, err
 				}
 
+				start = p.tokens.Offset()
 				continue
 			}
 
@@ -801,6 +2168,7 @@ func (p *Parser) tabular(e EnvironmentStart) (*Node, bool, error) {
 			if string(c) == "\\hline" {
 				addHanging()
 				rows = append(rows, &Node{Kind: ElementKind, Data: "\\hline"})
+				start = p.tokens.Offset()
 				continue
 			}
 
@@ -808,29 +2176,84 @@ func (p *Parser) tabular(e EnvironmentStart) (*Node, bool, error) {
 			if string(c) == "\\cline" {
 				rng, _, err := p.parameterVerbatim()
 				if err != nil {
-					return nil, false, err
+					return nil, err
 				}
 
-				addHanging()
-				rows = append(rows, &Node{Kind: ElementKind, Data: "\\cline", Parameters: map[string]string{"range": rng}})
-				continue
-			}
+				addHanging()
+				rows = append(rows, &Node{Kind: ElementKind, Data: "\\cline", Parameters: map[string]string{"range": rng}})
+				start = p.tokens.Offset()
+				continue
+			}
+		}
+
+		// stopped by environment end or end of input, exit
+		if _, ok := last.(EnvironmentEnd); ok || last == nil {
+			addCell(children, nil, end)
+			addHanging()
+			break
+		}
+	}
+
+	markCaption(rows, columns)
+
+	return rows, nil
+}
+
+// validateRow reports row having more cells than columns defined in the colspec, cells spanning multiple columns
+// are counted accordingly
+func (p *Parser) validateRow(row *Node, columns int) {
+	cells := 0
+	for _, cell := range row.Children {
+		span, err := strconv.Atoi(cell.Parameters["colspan"])
+		if err != nil || span < 1 {
+			span = 1
+		}
+
+		cells += span
+	}
+
+	if cells > columns {
+		p.recover(fmt.Errorf("row has %d cells, but colspec defines %d columns", cells, columns))
+	}
+}
+
+// markCaption looks for a pseudo-caption in a table: the first or the last row consisting of a single
+// \multicolumn cell spanning all columns. Such row is marked with "caption" parameter. Tables without known number of
+// columns (colspec is missing or invalid) have no captions.
+func markCaption(rows []*Node, columns int) {
+	isCaption := func(row *Node) bool {
+		if len(row.Children) != 1 {
+			return false
+		}
+
+		span, err := strconv.Atoi(row.Children[0].Parameters["colspan"])
+		return err == nil && columns > 0 && span > 1 && span >= columns
+	}
+
+	var first, last *Node
+	for _, row := range rows {
+		if row.Data != "\\row" {
+			continue
 		}
 
-		// stopped by environment end, exit
-		if _, ok := last.(EnvironmentEnd); ok {
-			addCell(children, nil)
-			addHanging()
-			break
+		if first == nil {
+			first = row
 		}
-	}
 
-	params := map[string]string{"colspec": colspec}
-	if pos != "" {
-		params["pos"] = pos
+		last = row
 	}
 
-	return &Node{Kind: ElementKind, Parameters: params, Data: e.Name, Children: rows}, false, nil
+	for _, row := range []*Node{first, last} {
+		if row == nil || !isCaption(row) {
+			continue
+		}
+
+		if row.Parameters == nil {
+			row.Parameters = map[string]string{}
+		}
+
+		row.Parameters["caption"] = "true"
+	}
 }
 
 // eatATab skips all whitespaces and if it sees & reads it
@@ -840,7 +2263,12 @@ func (p *Parser) eatATab() error {
 		return err
 	}
 
+	// fragments of tables may end right after the cell
 	next, err := p.tokens.Peek()
+	if err == io.EOF {
+		return nil
+	}
+
 	if err != nil {
 		return err
 	}
@@ -950,6 +2378,61 @@ func (p *Parser) wrapfigure(e EnvironmentStart) (*Node, bool, error) {
 	return &Node{Kind: ElementKind, Data: e.Name, Parameters: params, Children: children}, false, nil
 }
 
+// multicols reads multicols environment: \\begin{multicols}{3}
+func (p *Parser) multicols(e EnvironmentStart) (*Node, bool, error) {
+	columns, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid multicols columns parameter: %w", err)
+	}
+
+	children, _, err := p.vertical(func(a any, err error) bool {
+		n, ok := a.(EnvironmentEnd)
+		return err == nil && ok && n.Name == e.Name
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &Node{Kind: ElementKind, Data: e.Name, Parameters: map[string]string{"columns": columns}, Children: children}, false, nil
+}
+
+// minipage reads minipage environment: \\begin{minipage}[pos][height][inner-pos]{width}
+func (p *Parser) minipage(e EnvironmentStart) (*Node, bool, error) {
+	params := map[string]string{}
+
+	for _, key := range []string{"position", "height", "inner"} {
+		val, ok, err := p.optionVerbatim()
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid minipage %s parameter: %w", key, err)
+		}
+
+		if !ok {
+			break
+		}
+
+		params[key] = val
+	}
+
+	width, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid minipage width parameter: %w", err)
+	}
+
+	params["width"] = width
+
+	children, _, err := p.vertical(func(a any, err error) bool {
+		n, ok := a.(EnvironmentEnd)
+		return err == nil && ok && n.Name == e.Name
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &Node{Kind: ElementKind, Data: e.Name, Parameters: params, Children: children}, false, nil
+}
+
 func (p *Parser) lstListingEnvironment(e EnvironmentStart) (*Node, bool, error) {
 	opt, _, err := p.optionVerbatim()
 	if err != nil {
@@ -957,15 +2440,105 @@ func (p *Parser) lstListingEnvironment(e EnvironmentStart) (*Node, bool, error)
 	}
 
 	node, inline, err := p.verbatimEnvironment(e)
-	if opt != "" && node != nil {
+	if node == nil {
+		return node, inline, err
+	}
+
+	// options of the environment override defaults set by \\lstset
+	options := map[string]string{}
+	for key, value := range p.listing {
+		options[key] = value
+	}
+
+	if opt != "" {
 		node.Parameters = map[string]string{"options": opt}
+
+		if kv, err := KeyValue(opt); err == nil {
+			for key, value := range kv {
+				options[key] = value
+			}
+		}
+	}
+
+	// common listing options are copied to dedicated parameters, so they don't have to be parsed again
+	for _, key := range []string{"language", "caption", "label", "numbers", "firstnumber"} {
+		if v, ok := options[key]; ok {
+			if node.Parameters == nil {
+				node.Parameters = map[string]string{}
+			}
+
+			node.Parameters[key] = v
+		}
+	}
+
+	return node, inline, err
+}
+
+// lstset reads \\lstset{options} command, options are used as defaults for the following lstlisting environments
+func (p *Parser) lstset(c Command) (*Node, bool, error) {
+	options, _, err := p.parameterBalanced()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %v options parameter: %w", c, err)
+	}
+
+	kv, err := KeyValue(options)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %v options parameter: %w", c, err)
+	}
+
+	for key, value := range kv {
+		p.listing[key] = value
+	}
+
+	return &Node{Kind: ElementKind, Data: string(c), Parameters: map[string]string{"options": options}}, false, nil
+}
+
+// verbatimInput reads \\verbatiminput{file} command, content of the file is loaded as verbatim environment if there
+// is a loader, otherwise command is kept with file name in "src" parameter
+func (p *Parser) verbatimInput(c Command) (*Node, bool, error) {
+	src, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %v file parameter: %w", c, err)
+	}
+
+	params := map[string]string{"src": src}
+	if p.loader == nil {
+		return &Node{Kind: ElementKind, Data: string(c), Parameters: params}, false, nil
+	}
+
+	content, err := p.loader(src)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to load %v: %w", src, err)
+	}
+
+	return &Node{Kind: ElementKind, Data: "verbatim", Parameters: params, Children: []*Node{{Kind: TextKind, Data: content}}}, false, nil
+}
+
+// mintedEnvironment reads minted environment: \\begin{minted}[options]{language}
+func (p *Parser) mintedEnvironment(e EnvironmentStart) (*Node, bool, error) {
+	opt, _, err := p.optionVerbatim()
+	if err != nil {
+		return nil, false, err
+	}
+
+	language, _, err := p.parameterVerbatim()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid minted language parameter: %w", err)
+	}
+
+	node, inline, err := p.verbatimEnvironment(e)
+	if node != nil {
+		node.Parameters = map[string]string{"language": language}
+		if opt != "" {
+			node.Parameters["options"] = opt
+		}
 	}
 
 	return node, inline, err
 }
 
 func (p *Parser) verbatimEnvironment(e EnvironmentStart) (*Node, bool, error) {
-	content := ""
+	content := strings.Builder{}
 	suffix := "\\end{" + e.Name + "}"
 
 	if err := p.tokens.SkipEOL(); err != nil {
@@ -973,15 +2546,171 @@ func (p *Parser) verbatimEnvironment(e EnvironmentStart) (*Node, bool, error) {
 	}
 
 	_, err := p.tokens.Verbatim(func(r rune, err error) bool {
-		content += string(r)
-		return err == io.EOF || strings.HasSuffix(content, suffix)
+		content.WriteRune(r)
+		return err == io.EOF || isVerbatimEnd(content.String(), e.Name)
+	})
+
+	if err == io.EOF {
+		err = nil
+	}
+
+	return &Node{Kind: ElementKind, Data: e.Name, Children: []*Node{{Kind: TextKind, Data: strings.TrimSuffix(content.String(), suffix)}}}, false, err
+}
+
+// mathEnvironment reads amsmath environment (like cases or matrix) as is, so "&" and "\\\\" are preserved as a part of
+// math content instead of being parsed as text
+func (p *Parser) mathEnvironment(e EnvironmentStart) (*Node, bool, error) {
+	content := strings.Builder{}
+	suffix := "\\end{" + e.Name + "}"
+
+	_, err := p.tokens.Verbatim(func(r rune, err error) bool {
+		content.WriteRune(r)
+		return err == io.EOF || strings.HasSuffix(content.String(), suffix)
 	})
 
 	if err == io.EOF {
 		err = nil
 	}
 
-	return &Node{Kind: ElementKind, Data: e.Name, Children: []*Node{{Kind: TextKind, Data: strings.TrimSuffix(content, suffix)}}}, false, err
+	return &Node{Kind: ElementKind, Data: e.Name, Children: []*Node{{Kind: TextKind, Data: strings.TrimSuffix(content.String(), suffix)}}}, true, err
+}
+
+// formulaEnvironment reads math and displaymath environments as $ and $$ formulas, name of the environment is kept
+// in "environment" parameter, so formula can be rendered back the way it was written
+func (p *Parser) formulaEnvironment(e EnvironmentStart) (*Node, bool, error) {
+	node, _, err := p.mathEnvironment(e)
+	if node == nil {
+		return nil, false, err
+	}
+
+	node.Parameters = map[string]string{"environment": e.Name}
+	if e.Name == "displaymath" {
+		node.Data = "$$"
+		return node, false, err
+	}
+
+	node.Data = "$"
+	return node, true, err
+}
+
+// equationEnvironment reads equation and align environments as is, like other math environments. Explicit tag given by
+// \\tag{...} is stored in "tag" parameter and "notag" parameter is set if numbering is suppressed by \\notag or
+// \\nonumber, so numbering can take them into account.
+func (p *Parser) equationEnvironment(e EnvironmentStart) (*Node, bool, error) {
+	node, _, err := p.mathEnvironment(e)
+	if node == nil {
+		return nil, false, err
+	}
+
+	tag, notag := equationTag(node.Children[0].Data)
+	if tag != "" || notag {
+		node.Parameters = map[string]string{}
+	}
+
+	if tag != "" {
+		node.Parameters["tag"] = tag
+	}
+
+	if notag {
+		node.Parameters["notag"] = "true"
+	}
+
+	return node, false, err
+}
+
+// equationTag scans math content for \\tag{...} (or \\tag*{...}), \\notag and \\nonumber commands. If there are
+// several tags (for example in align environment), the first one is returned.
+func equationTag(math string) (tag string, notag bool) {
+	for i := 0; i < len(math); i++ {
+		if math[i] != '\\' {
+			continue
+		}
+
+		// read command name, escaped symbols like "\\\\" are skipped as a whole
+		j := i + 1
+		for j < len(math) && isLetter(rune(math[j])) {
+			j++
+		}
+
+		if j == i+1 {
+			i++
+			continue
+		}
+
+		switch math[i+1 : j] {
+		case "notag", "nonumber":
+			notag = true
+		case "tag":
+			if j < len(math) && math[j] == '*' {
+				j++
+			}
+
+			if j >= len(math) || math[j] != '{' {
+				break
+			}
+
+			depth, start := 0, j+1
+			for ; j < len(math); j++ {
+				if math[j] == '{' {
+					depth++
+				}
+
+				if math[j] == '}' {
+					depth--
+				}
+
+				if depth == 0 {
+					break
+				}
+			}
+
+			if tag == "" && j < len(math) {
+				tag = math[start:j]
+			}
+		}
+
+		i = j - 1
+	}
+
+	return
+}
+
+// ReadArgument reads obligatory argument wrapped in {} and parses its content in horizontal mode. Whitespaces
+// before the argument are skipped. If next character is not "{", nothing is consumed and ok is false.
+func (p *Parser) ReadArgument() (children []*Node, ok bool, err error) {
+	return p.parameter()
+}
+
+// ReadArgumentRaw reads obligatory argument wrapped in {} without parsing its content. Escape sequences (like "\\}")
+// are unescaped. Whitespaces before the argument are skipped. If next character is not "{", nothing is consumed and
+// ok is false.
+func (p *Parser) ReadArgumentRaw() (str string, ok bool, err error) {
+	return p.parameterVerbatim()
+}
+
+// ReadArgumentString reads obligatory argument like ReadArgument and converts its content to plain string, it
+// returns an error if the argument contains anything but text.
+func (p *Parser) ReadArgumentString() (str string, ok bool, err error) {
+	return p.parameterString()
+}
+
+// ReadOptional reads optional argument wrapped in [] and parses its content in horizontal mode. Unlike
+// ReadArgument, whitespaces are not skipped, "[" must follow immediately. If next character is not "[", nothing
+// is consumed and ok is false.
+func (p *Parser) ReadOptional() (children []*Node, ok bool, err error) {
+	return p.option()
+}
+
+// ReadOptionalRaw reads optional argument wrapped in [] without parsing its content. Escape sequences (like "\\]")
+// are unescaped. If next character is not "[", nothing is consumed and ok is false.
+func (p *Parser) ReadOptionalRaw() (str string, ok bool, err error) {
+	return p.optionVerbatim()
+}
+
+// ReadOptionalString reads optional argument like ReadOptional and converts its content to plain string, it
+// returns an error if the argument contains anything but text.
+func (p *Parser) ReadOptionalString() (str string, ok bool, err error) {
+	return p.optionString()
 }
 
 // option reads optional parameter (wrapped in []) if token "t" is optional parameter start.
@@ -1052,9 +2781,7 @@ func (p *Parser) optionVerbatim() (string, bool, error) {
 		return r == ']' // stop when we found unescaped bracket
 	})
 
-	for f, t := range escSeq {
-		val = strings.ReplaceAll(val, f, t)
-	}
+	val = escSeq.Replace(val)
 
 	return val, true, err
 }
@@ -1094,16 +2821,77 @@ func (p *Parser) parameter() (children []*Node, ok bool, err error) {
 		return nil, false, fmt.Errorf("expected parameter group beginning, but got %T instead", open)
 	}
 
+	var last any
 	val, err := p.horizontal(func(a any, err error) bool {
-		_, ok := a.(ParameterEnd)
-		return err == nil && ok
+		last = a
+		return p.groupEnd(a, err)
 	})
 
-	return val, true, err
+	if err != nil {
+		return nil, false, err
+	}
+
+	return val, true, p.closeGroup(last)
+}
+
+// groupEnd is a stop function for reading groups, normally group ends with "}", but inside table cells it's
+// also implicitly closed at cell boundary ("&", row break or end of environment), \\newline inside a group is a
+// line break within the cell, it ends the row only at the top level of the cell
+func (p *Parser) groupEnd(a any, err error) bool {
+	if err != nil {
+		return false
+	}
+
+	if _, ok := a.(ParameterEnd); ok {
+		return true
+	}
+
+	if p.tables == 0 {
+		return false
+	}
+
+	switch token := a.(type) {
+	case Symbol:
+		return token == "&"
+	case Command:
+		return token == "\\\\" || token == "\\\\*"
+	case EnvironmentEnd:
+		return true
+	default:
+		return false
+	}
+}
+
+// closeGroup checks token which stopped group reading, if group was implicitly closed at cell boundary, the token
+// is returned back, so table can continue from it
+func (p *Parser) closeGroup(last any) error {
+	if _, ok := last.(ParameterEnd); ok || last == nil {
+		return nil
+	}
+
+	err := errors.New("group is not closed at the end of table cell")
+	if p.strict {
+		return err
+	}
+
+	p.recover(err)
+
+	return p.tokens.Unread()
 }
 
 // parameterVerbatim reads obligatory parameter in verbatim mode
 func (p *Parser) parameterVerbatim() (str string, ok bool, err error) {
+	return p.parameterUnescaped(escSeq)
+}
+
+// parameterURL reads obligatory parameter with URL in verbatim mode, unlike parameterVerbatim escaped %, # and
+// spaces are unescaped too
+func (p *Parser) parameterURL() (str string, ok bool, err error) {
+	return p.parameterUnescaped(urlSeq)
+}
+
+// parameterUnescaped reads obligatory parameter in verbatim mode and unescapes it with given sequences
+func (p *Parser) parameterUnescaped(seq *strings.Replacer) (str string, ok bool, err error) {
 	if err := p.tokens.Skip(); err != nil {
 		return "", false, err
 	}
@@ -1145,10 +2933,60 @@ func (p *Parser) parameterVerbatim() (str string, ok bool, err error) {
 		return r == '}' // stop when we found unescaped bracket
 	})
 
-	for f, t := range escSeq {
-		val = strings.ReplaceAll(val, f, t)
+	val = seq.Replace(val)
+
+	return val, true, err
+}
+
+// parameterBalanced reads obligatory parameter as is, unlike parameterVerbatim it keeps nested groups, so parameter
+// ends at "}" matching the opening one
+func (p *Parser) parameterBalanced() (str string, ok bool, err error) {
+	if err := p.tokens.Skip(); err != nil {
+		return "", false, err
+	}
+
+	char, err := p.tokens.Peek()
+	if err == io.EOF {
+		return "", false, nil
+	}
+
+	if err != nil || char != '{' {
+		return "", false, err
+	}
+
+	open, err := p.tokens.Token()
+	if err != nil {
+		return "", false, err
+	}
+
+	if _, ok := open.(ParameterStart); !ok {
+		return "", false, fmt.Errorf("expected parameter group beginning, but got %T instead", open)
 	}
 
+	depth := 0
+	escape := false
+	val, err := p.tokens.Verbatim(func(r rune, err error) bool {
+		if err != nil {
+			return err == io.EOF
+		}
+
+		if escape { // previous rune was \, so ignore this one
+			escape = false
+			return false
+		}
+
+		switch r {
+		case '\\':
+			escape = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+
+		return depth < 0 // stop when we found bracket closing the parameter
+	})
+
 	return val, true, err
 }
 
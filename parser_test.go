@@ -4,13 +4,22 @@ import (
 	"github.com/eolymp/go-latex"
 	"github.com/google/go-cmp/cmp"
 
+	"errors"
 	"strings"
 	"testing"
+	"time"
 )
 
 var nbsp = string([]rune{0x00A0})
 
-func TestParser(t *testing.T) {
+// parserTest is a test case of TestParser, inputs of the cases are also used as seed corpus of FuzzParse
+type parserTest struct {
+	name   string
+	input  string
+	output *latex.Node
+}
+
+func parserTests() []parserTest {
 	doc := func(children ...*latex.Node) *latex.Node {
 		return &latex.Node{Kind: latex.DocumentKind, Children: children}
 	}
@@ -31,11 +40,7 @@ func TestParser(t *testing.T) {
 		return &latex.Node{Kind: latex.ElementKind, Data: command, Parameters: params, Children: children}
 	}
 
-	tt := []struct {
-		name   string
-		input  string
-		output *latex.Node
-	}{
+	return []parserTest{
 		{
 			name:   "simple paragraph",
 			input:  "one two\nthree",
@@ -98,6 +103,28 @@ func TestParser(t *testing.T) {
 				par(text(" bar")),
 			),
 		},
+		{
+			name:  "equations",
+			input: "\\begin{equation}x_i = 1 \\tag{$\\ast$}\\end{equation}\\begin{align*}a &= b\\end{align*}\\begin{align}a &= b \\notag \\\\ c &= d \\tag*{A}\\end{align}",
+			output: doc(
+				elementp("equation", map[string]string{"tag": "$\\ast$"}, text("x_i = 1 \\tag{$\\ast$}")),
+				element("align*", text("a &= b")),
+				elementp("align", map[string]string{"tag": "A", "notag": "true"}, text("a &= b \\notag \\\\ c &= d \\tag*{A}")),
+			),
+		},
+		{
+			name:  "math environments",
+			input: "foo \\begin{math}a_i^2 & b\\end{math} bar\\begin{displaymath}a_i^2 & b\\end{displaymath}baz",
+			output: doc(
+				par(
+					text("foo "),
+					elementp("$", map[string]string{"environment": "math"}, text("a_i^2 & b")),
+					text(" bar"),
+				),
+				elementp("$$", map[string]string{"environment": "displaymath"}, text("a_i^2 & b")),
+				par(text("baz")),
+			),
+		},
 		{
 			name:  "image",
 			input: "This is an image \\includegraphics[scale=1.5]{eolymp.png} some text after...",
@@ -131,6 +158,47 @@ func TestParser(t *testing.T) {
 			input:  "bar\\begin{comment}comment\\foo\\end{comment}baz",
 			output: doc(par(text("barbaz"))),
 		},
+		{
+			name:   "block comment with escaped end",
+			input:  "bar\\begin{comment}one\\\\end{comment} two\\\\\\end{comment}baz",
+			output: doc(par(text("barbaz"))),
+		},
+		{
+			name:  "verbatim ending with backslash",
+			input: "\\begin{verbatim}\nC:\\\\end{verbatim}\n\nAfter text",
+			output: doc(
+				element("verbatim", text("C:\\")),
+				par(text("\n")),
+				par(text("After text")),
+			),
+		},
+		{
+			name:  "verbatim ending with line break",
+			input: "\\begin{verbatim}\na\\\\\\end{verbatim}b",
+			output: doc(
+				element("verbatim", text("a\\\\")),
+				par(text("b")),
+			),
+		},
+		{
+			name:  "listing ending with backslash",
+			input: "\\begin{lstlisting}\ndir C:\\\\end{lstlisting}",
+			output: doc(
+				element("lstlisting", text("dir C:\\")),
+			),
+		},
+		{
+			name:  "block comment inside verbatim",
+			input: "\\begin{verbatim}\n\\begin{comment}x\\end{comment}\n\\end{verbatim}",
+			output: doc(
+				element("verbatim", text("\\begin{comment}x\\end{comment}\n")),
+			),
+		},
+		{
+			name:   "text superscript and subscript",
+			input:  "1\\textsuperscript{st} place, H\\textsubscript{2}O",
+			output: doc(par(text("1"), element("\\textsuperscript", text("st")), text(" place, H"), element("\\textsubscript", text("2")), text("O"))),
+		},
 		{
 			name:  "verb command",
 			input: "The \\verb|\\ldots| command \\ldots",
@@ -241,6 +309,41 @@ func TestParser(t *testing.T) {
 			input:  "\\sout{This text is struck out.}",
 			output: doc(par(element("\\sout", text("This text is struck out.")))),
 		},
+		{
+			name:  "soul formatting",
+			input: "\\st{struck} \\ul{underlined} \\hl{marked} \\hl[yellow]{colored}",
+			output: doc(par(
+				element("\\st", text("struck")),
+				text(" "),
+				element("\\ul", text("underlined")),
+				text(" "),
+				element("\\hl", text("marked")),
+				text(" "),
+				elementp("\\hl", map[string]string{"color": "yellow"}, text("colored")),
+			)),
+		},
+		{
+			name:  "phantoms",
+			input: "a\\phantom{bc}d \\hphantom{$x$} \\vphantom{Tall}",
+			output: doc(par(
+				text("a"),
+				element("\\phantom", text("bc")),
+				text("d "),
+				element("\\hphantom", element("$", text("x"))),
+				text(" "),
+				element("\\vphantom", text("Tall")),
+			)),
+		},
+		{
+			name:  "raisebox",
+			input: "x\\raisebox{-0.5ex}{\\textbf{b}} y\\raisebox{2pt}[10pt][0pt]{up}",
+			output: doc(par(
+				text("x"),
+				elementp("\\raisebox", map[string]string{"lift": "-0.5ex"}, element("\\textbf", text("b"))),
+				text(" y"),
+				elementp("\\raisebox", map[string]string{"lift": "2pt", "height": "10pt", "depth": "0pt"}, text("up")),
+			)),
+		},
 		{
 			name:   "cf11",
 			input:  "\\textsc{This text is capitalized.}",
@@ -302,6 +405,86 @@ func TestParser(t *testing.T) {
 				),
 			),
 		},
+		{
+			name:  "enumerate with label options",
+			input: "\\begin{enumerate}[label=(\\alph*)]\n\\item First\n\\end{enumerate}",
+			output: doc(
+				elementp("enumerate", map[string]string{"options": "label=(\\alph*)"},
+					element("\\item", par(text("First\n"))),
+				),
+			),
+		},
+		{
+			name:  "itemize with spacing options",
+			input: "\\begin{itemize}[noitemsep,leftmargin=*]\n\\item One\n\\item Two\n\\end{itemize}",
+			output: doc(
+				elementp("itemize", map[string]string{"options": "noitemsep,leftmargin=*"},
+					element("\\item", par(text("One\n"))),
+					element("\\item", par(text("Two\n"))),
+				),
+			),
+		},
+		{
+			name:   "empty math",
+			input:  "$$$$",
+			output: doc(element("$$", text(""))),
+		},
+		{
+			name:  "highlighted example line",
+			input: "3\n\\showln{1 2 3}\n",
+			output: doc(par(
+				text("3\n"),
+				elementp("\\showln", map[string]string{"highlight": "true"}, text("1 2 3")),
+				text("\n"),
+			)),
+		},
+		{
+			name:  "minipage",
+			input: "\\begin{minipage}{0.5\\textwidth}Left\\end{minipage}\\begin{minipage}[t][3cm]{4cm}Right\\end{minipage}",
+			output: doc(
+				elementp("minipage", map[string]string{"width": "0.5\\textwidth"}, par(text("Left"))),
+				elementp("minipage", map[string]string{"position": "t", "height": "3cm", "width": "4cm"}, par(text("Right"))),
+			),
+		},
+		{
+			name:  "multicols",
+			input: "\\begin{multicols}{3}Text\\end{multicols}",
+			output: doc(
+				elementp("multicols", map[string]string{"columns": "3"}, par(text("Text"))),
+			),
+		},
+		{
+			name:  "flush environments",
+			input: "\\begin{flushleft}Left\\end{flushleft}\\begin{flushright}Right\\end{flushright}",
+			output: doc(
+				element("flushleft", par(text("Left"))),
+				element("flushright", par(text("Right"))),
+			),
+		},
+		{
+			name:   "counters",
+			input:  "\\newcounter{step}\\stepcounter{step}Step \\arabic{step}, \\stepcounter{step}step \\roman{step}, \\setcounter{step}{3}step \\Alph{step}, \\addtocounter{step}{-1}step \\arabic{step}.",
+			output: doc(par(text("Step 1, step ii, step C, step 2."))),
+		},
+		{
+			name:  "appendix",
+			input: "\\section{Intro}\n\\appendix\n\\section{Proofs}",
+			output: doc(
+				par(element("\\section", text("Intro")), text("\n")),
+				element("\\appendix"),
+				par(element("\\section", text("Proofs"))),
+			),
+		},
+		{
+			name:  "center with leading line break",
+			input: "\\begin{center}\\\\ text \\end{center}",
+			output: doc(
+				element("center",
+					elementp("\\\\", map[string]string{"space": " "}),
+					par(text("text ")),
+				),
+			),
+		},
 		{
 			name:  "cf23",
 			input: "Some C++ source code (auto-detecting and highlighting):\n\\begin{lstlisting}\n#include <iostream>\nint main() {\n    int a, b;\n    std::cin >> a >> b;\n    std::cout << a + b << std::endl;\n}\n\\end{lstlisting}",
@@ -315,14 +498,90 @@ func TestParser(t *testing.T) {
 			input: "Some C++ source code (auto-detecting and highlighting):\n\\begin{lstlisting}[language=C++]\n#include <iostream>\nint main() {\n    int a, b;\n    std::cin >> a >> b;\n    std::cout << a + b << std::endl;\n}\n\\end{lstlisting}",
 			output: doc(
 				par(text("Some C++ source code (auto-detecting and highlighting):\n")),
-				elementp("lstlisting", map[string]string{"options": "language=C++"}, text("#include <iostream>\nint main() {\n    int a, b;\n    std::cin >> a >> b;\n    std::cout << a + b << std::endl;\n}\n")),
+				elementp("lstlisting", map[string]string{"options": "language=C++", "language": "C++"}, text("#include <iostream>\nint main() {\n    int a, b;\n    std::cin >> a >> b;\n    std::cout << a + b << std::endl;\n}\n")),
 			),
 		},
 		{
 			name:  "lstlisting with whitespace prefix",
 			input: "\\begin{lstlisting}[language=C++]\n    int a, b;\n    std::cin >> a >> b;\n\\end{lstlisting}",
 			output: doc(
-				elementp("lstlisting", map[string]string{"options": "language=C++"}, text("    int a, b;\n    std::cin >> a >> b;\n")),
+				elementp("lstlisting", map[string]string{"options": "language=C++", "language": "C++"}, text("    int a, b;\n    std::cin >> a >> b;\n")),
+			),
+		},
+		{
+			name:  "lstlisting with caption, label and line numbers",
+			input: "\\begin{lstlisting}[language=Python, caption={Sum of a, b}, label=lst:sum, numbers=left, firstnumber=10]\nprint(a + b)\n\\end{lstlisting}",
+			output: doc(
+				elementp("lstlisting", map[string]string{
+					"options":     "language=Python, caption={Sum of a, b}, label=lst:sum, numbers=left, firstnumber=10",
+					"language":    "Python",
+					"caption":     "Sum of a, b",
+					"label":       "lst:sum",
+					"numbers":     "left",
+					"firstnumber": "10",
+				}, text("print(a + b)\n")),
+			),
+		},
+		{
+			name:  "minted",
+			input: "\\begin{minted}{python}\nprint(\"{}\" % 1) # \\end\n\\end{minted}\\begin{minted}[linenos]{c++}\nint a;\n\\end{minted}",
+			output: doc(
+				elementp("minted", map[string]string{"language": "python"}, text("print(\"{}\" % 1) # \\end\n")),
+				elementp("minted", map[string]string{"language": "c++", "options": "linenos"}, text("int a;\n")),
+			),
+		},
+		{
+			name:  "lstlisting with defaults set by lstset",
+			input: "\\lstset{language=C++,numbers=left}\\begin{lstlisting}\nint a;\n\\end{lstlisting}\\begin{lstlisting}[numbers=none]\nint b;\n\\end{lstlisting}",
+			output: doc(
+				elementp("\\lstset", map[string]string{"options": "language=C++,numbers=left"}),
+				elementp("lstlisting", map[string]string{"language": "C++", "numbers": "left"}, text("int a;\n")),
+				elementp("lstlisting", map[string]string{"options": "numbers=none", "language": "C++", "numbers": "none"}, text("int b;\n")),
+			),
+		},
+		{
+			name:   "relax and ignorespaces",
+			input:  "a\\relax b \\ignorespaces   \n  c",
+			output: doc(par(text("a"), element("\\relax"), text("b "), element("\\ignorespaces"), text("c"))),
+		},
+		{
+			name:   "csname resolving definition",
+			input:  "\\def\\foo{bar}Call \\csname foo\\endcsname{} and \\csname textbf\\endcsname{x}",
+			output: doc(par(text("Call bar and "), element("\\textbf", text("x")))),
+		},
+		{
+			name:   "expandafter is ignored",
+			input:  "a \\expandafter{b} c \\expandafter\\textbf{d}",
+			output: doc(par(text("a "), element("{}", text("b")), text(" c "), element("\\textbf", text("d")))),
+		},
+		{
+			name:   "string",
+			input:  "Use \\string\\foo, \\string{ and \\string x.",
+			output: doc(par(text("Use \\foo, { and x."))),
+		},
+		{
+			name:   "iffalse and iftrue",
+			input:  "a \\iffalse hidden \\iftrue nested \\fi still \\else shown \\fi b \\iftrue kept\\else dropped\\fi.",
+			output: doc(par(text("a shown b kept."))),
+		},
+		{
+			name:   "iffalse spanning paragraphs and environments",
+			input:  "a\n\n\\iffalse\n\\begin{center}\nhidden\n\\end{center}\n\n\\fi\nb",
+			output: doc(par(text("a\n")), par(text("b"))),
+		},
+		{
+			name:   "ifdefined",
+			input:  "\\def\\foo{x}\\ifdefined\\foo yes\\else no\\fi, \\ifdefined\\bar yes\\else no\\fi",
+			output: doc(par(text("yes, no"))),
+		},
+		{
+			name:  "newenvironment",
+			input: "\\newenvironment{note}[2][Note]{\\begin{center}\\textbf{#1 #2:} }{\\end{center}}\\begin{note}{1}Text \\begin{note}[Tip]{2}x\\end{note}\\end{note}",
+			output: doc(
+				element("{}", element("center",
+					par(element("\\textbf", text("Note 1:")), text(" Text ")),
+					element("{}", element("center", par(element("\\textbf", text("Tip 2:")), text(" x")))),
+				)),
 			),
 		},
 		{
@@ -343,6 +602,13 @@ func TestParser(t *testing.T) {
 				text("."),
 			)),
 		},
+		{
+			name:  "href with options",
+			input: "\\href[pdfnewwindow=true, color=red]{https://eolymp.com/}{Eolymp}",
+			output: doc(par(
+				elementp("\\href", map[string]string{"href": "https://eolymp.com/", "options": "pdfnewwindow=true, color=red"}, text("Eolymp")),
+			)),
+		},
 		{
 			name:  "cf26",
 			input: "\\begin{center}\n  This content is centered.\n\n  $abacaba$\n\\end{center}",
@@ -366,7 +632,7 @@ func TestParser(t *testing.T) {
 				par(text("\n  ")),
 				elementp("\\includegraphics", map[string]string{"src": "eolymp.png"}),
 				par(text(" ")),
-				element("\\\\"),
+				elementp("\\\\", map[string]string{"space": "\n  "}),
 				par(
 					element("\\small", text("Centered unscaled image.")),
 					text("\n"),
@@ -380,7 +646,7 @@ func TestParser(t *testing.T) {
 				par(text("\n  ")),
 				elementp("\\includegraphics", map[string]string{"src": "eolymp.png", "options": "scale=1.5"}),
 				par(text(" ")),
-				element("\\\\"),
+				elementp("\\\\", map[string]string{"space": "\n  "}),
 				par(
 					element("\\small", text("Centered scaled image.")),
 					text("\n"),
@@ -394,7 +660,7 @@ func TestParser(t *testing.T) {
 				par(text("\n    ")),
 				elementp("\\includegraphics", map[string]string{"src": "eolymp.png", "options": "width=4cm"}),
 				par(text(" ")),
-				element("\\\\"),
+				elementp("\\\\", map[string]string{"space": "\n  "}),
 				par(element("\\small", text("Centered image with width specified (180px).")), text("\n")),
 			)),
 		},
@@ -415,6 +681,183 @@ func TestParser(t *testing.T) {
 				),
 			),
 		},
+		{
+			name:  "ampersand inside math in table cell",
+			input: "\\begin{tabular}{ll}\n$x&y$ & z \\\\\n\\end{tabular}",
+			output: doc(
+				elementp("tabular", map[string]string{"colspec": "ll"},
+					element("\\row",
+						element("\\cell", par(text("\n"), element("$", text("x&y")), text(" "))),
+						element("\\cell", par(text(" z "))),
+					),
+				),
+			),
+		},
+		{
+			name:  "math environment outside of math mode",
+			input: "\\[ \\begin{cases} a & b \\\\ c & d \\end{cases} \\]",
+			output: doc(par(
+				text("[ "),
+				element("cases", text(" a & b \\\\ c & d ")),
+				text(" ]"),
+			)),
+		},
+		{
+			name:  "array environment",
+			input: "$$\\begin{array}{cc} a & b \\end{array}$$\\begin{array}{|c|} x \\\\ \\hline y \\end{array}",
+			output: doc(
+				element("$$", text("\\begin{array}{cc} a & b \\end{array}")),
+				par(element("array", text("{|c|} x \\\\ \\hline y "))),
+			),
+		},
+		{
+			name:  "tables with target width",
+			input: "\\begin{tabular*}{10cm}[t]{ll}a & b\\end{tabular*}\\begin{tabularx}{\\textwidth}{lX}c & d\\end{tabularx}",
+			output: doc(
+				elementp("tabular*", map[string]string{"colspec": "ll", "pos": "t", "width": "10cm"},
+					element("\\row",
+						element("\\cell", par(text("a "))),
+						element("\\cell", par(text(" b"))),
+					),
+				),
+				elementp("tabularx", map[string]string{"colspec": "lX", "width": "\\textwidth"},
+					element("\\row",
+						element("\\cell", par(text("c "))),
+						element("\\cell", par(text(" d"))),
+					),
+				),
+			),
+		},
+		{
+			name:  "newline inside group in paragraph cell",
+			input: "\\begin{tabular}{p{3cm}l}{a\\newline b} & c \\\\ d & e\\end{tabular}",
+			output: doc(
+				elementp("tabular", map[string]string{"colspec": "p{3cm}l"},
+					element("\\row",
+						element("\\cell", element("{}", par(text("a")), element("\\newline"), par(text("b"))), par(text(" "))),
+						element("\\cell", par(text(" c "))),
+					),
+					element("\\row",
+						element("\\cell", par(text("d "))),
+						element("\\cell", par(text(" e"))),
+					),
+				),
+			),
+		},
+		{
+			name:  "custom column types",
+			input: "\\newcolumntype{C}{>{\\centering}p{2cm}}\\newcolumntype{R}[1]{>{\\raggedleft}p{#1}}\\begin{tabular}{|C|R{3cm}|}a & b\\end{tabular}",
+			output: doc(
+				elementp("tabular", map[string]string{"colspec": "|C|R{3cm}|", "columns": "|>{\\centering}p{2cm}|>{\\raggedleft}p{3cm}|"},
+					element("\\row",
+						element("\\cell", par(text("a "))),
+						element("\\cell", par(text(" b"))),
+					),
+				),
+			),
+		},
+		{
+			name:  "multicolumn spanning three columns",
+			input: "\\begin{tabular}{cccc}\\multicolumn{3}{c}{Title} & d \\\\\na & b & c & d \\\\\nx & \\multicolumn{3}{c}{Rest}\\end{tabular}",
+			output: doc(
+				elementp("tabular", map[string]string{"colspec": "cccc"},
+					element("\\row",
+						elementp("\\cell", map[string]string{"colspan": "3", "align": "c"}, par(text("Title"))),
+						element("\\cell", par(text(" d "))),
+					),
+					element("\\row",
+						element("\\cell", par(text("a "))),
+						element("\\cell", par(text(" b "))),
+						element("\\cell", par(text(" c "))),
+						element("\\cell", par(text(" d "))),
+					),
+					element("\\row",
+						element("\\cell", par(text("x "))),
+						elementp("\\cell", map[string]string{"colspan": "3", "align": "c"}, par(text("Rest"))),
+					),
+				),
+			),
+		},
+		{
+			name:  "non-breaking row break in table",
+			input: "\\begin{tabular}{ll}a & b \\\\*\nc & d \\\\ e & f\\end{tabular}",
+			output: doc(
+				elementp("tabular", map[string]string{"colspec": "ll"},
+					elementp("\\row", map[string]string{"nobreak": "true"},
+						element("\\cell", par(text("a "))),
+						element("\\cell", par(text(" b "))),
+					),
+					element("\\row",
+						element("\\cell", par(text("c "))),
+						element("\\cell", par(text(" d "))),
+					),
+					element("\\row",
+						element("\\cell", par(text("e "))),
+						element("\\cell", par(text(" f"))),
+					),
+				),
+			),
+		},
+		{
+			name:   "command definitions",
+			input:  "\\newcommand{\\x}{one}\\newcommand\\y[0]{two}\\x, \\y",
+			output: doc(par(text("one, two"))),
+		},
+		{
+			name:   "command definitions with arguments",
+			input:  "\\newcommand{\\foo}[1]{bar #1}\\foo{x}; \\providecommand\\hi[2][World]{Hello, #1#2}\\hi{!} \\hi[Bob]{.}",
+			output: doc(par(text("bar x; Hello, World! Hello, Bob."))),
+		},
+		{
+			name:   "providecommand does not override existing definition",
+			input:  "\\def\\x{old}\\providecommand{\\x}{new}\\providecommand{\\y}{provided}\\x, \\y; \\renewcommand{\\x}{renewed}\\x",
+			output: doc(par(text("old, provided; renewed"))),
+		},
+		{
+			name:   "let assignment",
+			input:  "\\let\\x\\ldots\\def\\a{A}\\let\\b = \\a\\x \\b",
+			output: doc(par(text("…A"))),
+		},
+		{
+			name:   "let alias of built-in command",
+			input:  "\\let\\bold\\textbf\\let\\strong=\\bold\\bold{a} \\strong{b}\\def\\bold{c}\\bold",
+			output: doc(par(element("\\textbf", text("a")), text(" "), element("\\textbf", text("b")), text("c"))),
+		},
+		{
+			name:  "caption with short form",
+			input: "\\caption[Short]{Long \\textbf{caption}} \\caption{Plain}",
+			output: doc(par(
+				elementp("\\caption", map[string]string{"short": "Short"}, text("Long "), element("\\textbf", text("caption"))),
+				text(" "),
+				element("\\caption", text("Plain")),
+			)),
+		},
+		{
+			name:  "subfigures",
+			input: "\\begin{figure}\\begin{subfigure}[b]{0.5\\textwidth}\\includegraphics{a.png}\\caption{A}\\end{subfigure}\\caption{Both}\\end{figure}",
+			output: doc(
+				element("figure",
+					elementp("subfigure", map[string]string{"position": "b", "width": "0.5\\textwidth"},
+						elementp("\\includegraphics", map[string]string{"src": "a.png"}),
+						par(element("\\caption", text("A"))),
+					),
+					par(element("\\caption", text("Both"))),
+				),
+			),
+		},
+		{
+			name:  "verse keeps line breaks inside paragraph",
+			input: "\\begin{verse}\nLine one \\\\\nLine two\n\nNext stanza\n\\end{verse}\nAfter \\\\ text",
+			output: doc(
+				element("verse",
+					par(text("\nLine one "), element("\\\\"), text("Line two\n")),
+					par(text("Next stanza\n")),
+				),
+				par(text("\nAfter ")),
+				elementp("\\\\", map[string]string{"space": " "}),
+				par(text("text")),
+			),
+		},
 		{
 			name:  "cf32",
 			input: "More complex table with borders:\n\\begin{tabular}{|l|c|r|} \\hline\n  Left aligned column & Centered column & Right aligned column \\\\ \\hline\n  Text & Text & Text \\\\ \\hline\n\\end{tabular}",
@@ -543,6 +986,37 @@ func TestParser(t *testing.T) {
 				),
 			),
 		},
+		{
+			name:  "table with caption row",
+			input: "\\begin{tabular}{|c|c|}\n\\multicolumn{2}{|c|}{Results} \\\\ \\hline\nA & B\n\\end{tabular}",
+			output: doc(
+				elementp("tabular", map[string]string{"colspec": "|c|c|"},
+					elementp("\\row", map[string]string{"caption": "true"},
+						elementp("\\cell", map[string]string{"colspan": "2", "align": "|c|"}, par(text("Results"))),
+					),
+					element("\\hline"),
+					element("\\row",
+						element("\\cell", par(text("A "))),
+						element("\\cell", par(text(" B\n"))),
+					),
+				),
+			),
+		},
+		{
+			name:  "table with caption row which can't be broken",
+			input: "\\begin{tabular}{cc}\nA & B \\\\\n\\multicolumn{2}{c}{Results} \\\\*\n\\end{tabular}",
+			output: doc(
+				elementp("tabular", map[string]string{"colspec": "cc"},
+					element("\\row",
+						element("\\cell", par(text("\nA "))),
+						element("\\cell", par(text(" B "))),
+					),
+					elementp("\\row", map[string]string{"nobreak": "true", "caption": "true"},
+						elementp("\\cell", map[string]string{"colspan": "2", "align": "c"}, par(text("Results"))),
+					),
+				),
+			),
+		},
 		{
 			name:  "cf37",
 			input: "If you want to quote single character, use single quotes: `a'.\n\nIn some statements use <<these double quotes>>. As for the long dashes~--- use these like that.\n\nIn English statements use ``these double quotes''. As for the long dashes~--- use these like that.",
@@ -552,6 +1026,20 @@ func TestParser(t *testing.T) {
 				par(text("In English statements use \"these double quotes\". As for the long dashes"+nbsp+"— use these like that.")),
 			),
 		},
+		{
+			name:  "discretionary hyphen and slash",
+			input: "hy\\-phen \\- input\\slash output",
+			output: doc(
+				par(text("hyphen  input"), element("\\slash"), text("output")),
+			),
+		},
+		{
+			name:  "spacing commands",
+			input: "e.g.\\ x etc.\\@ y 5\\,km a\\;b\\:c\\!d",
+			output: doc(
+				par(text("e.g. x etc. y 5\u2009km a\u2005b\u205fcd")),
+			),
+		},
 		{
 			name:  "cf38",
 			input: "\\epigraph{\\it{Some inspirational citation...}}{--- Author of citation, \\it{Source}}\nLegend starts here...",
@@ -587,6 +1075,16 @@ func TestParser(t *testing.T) {
 			input:  "\\begin{example}\nfoobar\\end{example}",
 			output: doc(element("example", par(text("\nfoobar")))),
 		},
+		{
+			name:   "theorem with name",
+			input:  "\\begin{theorem}[Pythagoras]\nFoo.\n\n\\end{theorem}\\begin{proof}Bar.\\end{proof}",
+			output: doc(elementp("theorem", map[string]string{"name": "Pythagoras"}, par(text("\nFoo.\n"))), element("proof", par(text("Bar.")))),
+		},
+		{
+			name:   "texorpdfstring",
+			input:  "\\texorpdfstring{$\\alpha$}{alpha}",
+			output: doc(par(elementp("\\texorpdfstring", map[string]string{"pdfstring": "alpha"}, element("$", text("\\alpha"))))),
+		},
 		{
 			name:   "whitespace between parameterString",
 			input:  "\\includegraphics[width=5cm, height=5cm] {xx.png}",
@@ -652,6 +1150,21 @@ func TestParser(t *testing.T) {
 			input:  "\\includegraphics{https://foo.com/www.bar.com/wp-content/uploads/2021/02/4cbe8d_f1ed2800a49649848102c68fc5a66e53mv2.gif?fit=476%2C280&ssl=1}",
 			output: doc(elementp("\\includegraphics", map[string]string{"src": "https://foo.com/www.bar.com/wp-content/uploads/2021/02/4cbe8d_f1ed2800a49649848102c68fc5a66e53mv2.gif?fit=476%2C280&ssl=1"})),
 		},
+		{
+			name:   "url with escaped percent",
+			input:  "\\url{https://example.com/?fit=476\\%2C280\\#top}",
+			output: doc(par(elementp("\\url", map[string]string{"href": "https://example.com/?fit=476%2C280#top"}))),
+		},
+		{
+			name:   "href with escaped space",
+			input:  "\\href{https://example.com/my\\ file.pdf}{file}",
+			output: doc(par(elementp("\\href", map[string]string{"href": "https://example.com/my file.pdf"}, text("file")))),
+		},
+		{
+			name:   "escaped percent outside of url",
+			input:  "\\texorpdfstring{50\\%}{50\\%}",
+			output: doc(par(elementp("\\texorpdfstring", map[string]string{"pdfstring": "50\\%"}, text("50%")))),
+		},
 		{
 			name:  "p12854",
 			input: "\\epigraph{Hello, and again, welcome to the Aperture Science Enrichment Center.}",
@@ -678,6 +1191,11 @@ func TestParser(t *testing.T) {
 				text(" to be a judge of this"),
 			)),
 		},
+		{
+			name:   "example with samples",
+			input:  "\\begin{example}\\exmp{1 2}{3}\\end{example}",
+			output: doc(element("example", elementp("\\exmp", map[string]string{"input": "1 2", "output": "3"}))),
+		},
 		{
 			name:   "verbatim parameter with {}",
 			input:  "\\exmp{\\{[]\\}}{OK}",
@@ -701,12 +1219,56 @@ func TestParser(t *testing.T) {
 			))),
 		},
 		{
-			name:  "tabs",
-			input: "\\begin{tabs}\n  \\item{Tab 1} This is the first item;\n  \\item{Tab 2} This is the second item.\n\\end{tabs}",
-			output: doc(
-				element("tabs",
-					elementp("\\item", map[string]string{"title": "Tab 1"}, par(text(" This is the first item;\n  "))),
-					elementp("\\item", map[string]string{"title": "Tab 2"}, par(text(" This is the second item.\n"))),
+			name:  "phantom section with contents line",
+			input: "\\phantomsection\\addcontentsline{toc}{section}{Notes}",
+			output: doc(par(
+				elementp("\\phantomsection", map[string]string{"anchor": "phantomsection-1"}),
+				elementp("\\addcontentsline", map[string]string{"file": "toc", "level": "section"}, text("Notes")),
+			)),
+		},
+		{
+			name:  "citations",
+			input: "See \\cite{knuth, lamport} and \\cite[p. 42]{knuth}.",
+			output: doc(par(
+				text("See "),
+				elementp("\\cite", map[string]string{"keys": "knuth,lamport"}),
+				text(" and "),
+				elementp("\\cite", map[string]string{"keys": "knuth", "note": "p. 42"}),
+				text("."),
+			)),
+		},
+		{
+			name:  "internal references",
+			input: "\\section{Intro}\\label{sec:intro} See \\autoref{sec:intro}, \\nameref{sec:intro} or \\hyperref[sec:intro]{the \\textbf{intro}}.",
+			output: doc(par(
+				element("\\section", text("Intro")),
+				elementp("\\label", map[string]string{"label": "sec:intro"}),
+				text(" See "),
+				elementp("\\autoref", map[string]string{"label": "sec:intro"}),
+				text(", "),
+				elementp("\\nameref", map[string]string{"label": "sec:intro"}),
+				text(" or "),
+				elementp("\\hyperref", map[string]string{"label": "sec:intro"}, text("the "), element("\\textbf", text("intro"))),
+				text("."),
+			)),
+		},
+		{
+			name:  "bibliography",
+			input: "\\begin{thebibliography}{9}\n\\bibitem{lamport} Leslie Lamport.\n\\bibitem[DK]{knuth} Donald Knuth.\n\\end{thebibliography}",
+			output: doc(
+				elementp("thebibliography", map[string]string{"widest": "9"},
+					elementp("\\bibitem", map[string]string{"key": "lamport"}, par(text(" Leslie Lamport.\n"))),
+					elementp("\\bibitem", map[string]string{"key": "knuth", "label": "DK"}, par(text(" Donald Knuth.\n"))),
+				),
+			),
+		},
+		{
+			name:  "tabs",
+			input: "\\begin{tabs}\n  \\item{Tab 1} This is the first item;\n  \\item{Tab 2} This is the second item.\n\\end{tabs}",
+			output: doc(
+				element("tabs",
+					elementp("\\item", map[string]string{"title": "Tab 1"}, par(text(" This is the first item;\n  "))),
+					elementp("\\item", map[string]string{"title": "Tab 2"}, par(text(" This is the second item.\n"))),
 				),
 			),
 		},
@@ -718,9 +1280,16 @@ func TestParser(t *testing.T) {
 				par(text("Як справи? ⁉️")),
 			)),
 		},
+		{
+			name:   "unknown starred environment",
+			input:  "\\begin{foo*}[x]Text\\end{foo*}",
+			output: doc(elementp("foo*", map[string]string{"options": "x"}, par(text("Text")))),
+		},
 	}
+}
 
-	for _, tc := range tt {
+func TestParser(t *testing.T) {
+	for _, tc := range parserTests() {
 		t.Run(tc.name, func(t *testing.T) {
 			parser := latex.NewParser(strings.NewReader(tc.input))
 
@@ -737,3 +1306,590 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDocument(t *testing.T) {
+	doc := func(children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.DocumentKind, Children: children}
+	}
+
+	text := func(t string) *latex.Node {
+		return &latex.Node{Kind: latex.TextKind, Data: t}
+	}
+
+	par := func(children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.ElementKind, Data: "\\par", Children: children}
+	}
+
+	elementp := func(command string, params map[string]string, children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.ElementKind, Data: command, Parameters: params, Children: children}
+	}
+
+	tt := []struct {
+		name     string
+		input    string
+		preamble *latex.Node
+		body     *latex.Node
+	}{
+		{
+			name:  "preamble and body",
+			input: "\\documentclass{article}\n\\usepackage[utf8]{inputenc}\n\\def\\N{100}\n\\begin{document}\nN is \\N.\n\\end{document}\nignored",
+			preamble: doc(
+				elementp("\\documentclass", map[string]string{"name": "article"}),
+				par(text("\n")),
+				elementp("\\usepackage", map[string]string{"name": "inputenc", "options": "utf8"}),
+			),
+			body: doc(par(text("\nN is 100.\n"))),
+		},
+		{
+			name:     "no document environment",
+			input:    "just a body",
+			preamble: doc(),
+			body:     doc(par(text("just a body"))),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			preamble, body, err := latex.ParseDocument(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			if !cmp.Equal(tc.preamble, preamble) {
+				t.Errorf("Preamble does not match:\n%s\n", cmp.Diff(tc.preamble, preamble))
+			}
+
+			if !cmp.Equal(tc.body, body) {
+				t.Errorf("Body does not match:\n%s\n", cmp.Diff(tc.body, body))
+			}
+		})
+	}
+}
+
+func TestParser_Diagnostics(t *testing.T) {
+	doc := func(children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.DocumentKind, Children: children}
+	}
+
+	text := func(t string) *latex.Node {
+		return &latex.Node{Kind: latex.TextKind, Data: t}
+	}
+
+	par := func(children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.ElementKind, Data: "\\par", Children: children}
+	}
+
+	tt := []struct {
+		name        string
+		input       string
+		output      *latex.Node
+		diagnostics []latex.Diagnostic
+	}{
+		{
+			name:        "no errors",
+			input:       "content",
+			output:      doc(par(text("content"))),
+			diagnostics: nil,
+		},
+		{
+			name:   "environment without name",
+			input:  "\\begin{}content\\end{}",
+			output: doc(par(text("content"))),
+			diagnostics: []latex.Diagnostic{
+				{Offset: 8, Message: "environment name is expected"},
+				{Offset: 21, Message: "unexpected \\end{} without matching \\begin"},
+			},
+		},
+		{
+			name:  "unclosed group in table cell",
+			input: "\\begin{tabular}{ll}{unclosed & \\textbf{b \\\\ c & d\\end{tabular}",
+			output: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "tabular", Parameters: map[string]string{"colspec": "ll"}, Children: []*latex.Node{
+					{Kind: latex.ElementKind, Data: "\\row", Children: []*latex.Node{
+						{Kind: latex.ElementKind, Data: "\\cell", Children: []*latex.Node{par(&latex.Node{Kind: latex.ElementKind, Data: "{}", Children: []*latex.Node{text("unclosed ")}})}},
+						{Kind: latex.ElementKind, Data: "\\cell", Children: []*latex.Node{par(text(" "), &latex.Node{Kind: latex.ElementKind, Data: "\\textbf", Children: []*latex.Node{text("b ")}})}},
+					}},
+					{Kind: latex.ElementKind, Data: "\\row", Children: []*latex.Node{
+						{Kind: latex.ElementKind, Data: "\\cell", Children: []*latex.Node{par(text("c "))}},
+						{Kind: latex.ElementKind, Data: "\\cell", Children: []*latex.Node{par(text(" d"))}},
+					}},
+				}},
+			),
+			diagnostics: []latex.Diagnostic{
+				{Offset: 30, Message: "group is not closed at the end of table cell"},
+				{Offset: 44, Message: "group is not closed at the end of table cell"},
+			},
+		},
+		{
+			name:   "undefined counter",
+			input:  "Step \\arabic{step}.",
+			output: doc(par(text("Step ."))),
+			diagnostics: []latex.Diagnostic{
+				{Offset: 18, Message: "counter \"step\" is not defined"},
+			},
+		},
+		{
+			name:   "stray environment end",
+			input:  "text \\end{center} more",
+			output: doc(par(text("text  more"))),
+			diagnostics: []latex.Diagnostic{
+				{Offset: 17, Message: "unexpected \\end{center} without matching \\begin"},
+			},
+		},
+		{
+			name:  "stray environment end inside another environment",
+			input: "\\begin{center}a\\end{itemize}b\\end{center}c",
+			output: doc(
+				&latex.Node{Kind: latex.ElementKind, Data: "center", Children: []*latex.Node{par(text("ab"))}},
+				par(text("c")),
+			),
+			diagnostics: []latex.Diagnostic{
+				{Offset: 28, Message: "unexpected \\end{itemize} without matching \\begin"},
+			},
+		},
+		{
+			name:   "csname with unknown command",
+			input:  "a \\csname nothing\\endcsname b",
+			output: doc(par(text("a b"))),
+			diagnostics: []latex.Diagnostic{
+				{Offset: 28, Message: "unknown command \\nothing"},
+			},
+		},
+		{
+			name:   "unknown conditional takes first branch",
+			input:  "\\ifodd 3 odd\\else even\\fi \\fi",
+			output: doc(par(text("3 odd"))),
+			diagnostics: []latex.Diagnostic{
+				{Offset: 29, Message: "unexpected \\fi without matching \\if"},
+			},
+		},
+		{
+			name:   "command redefined with newcommand",
+			input:  "\\newcommand{\\x}{a}\\newcommand{\\x}{b}\\x",
+			output: doc(par(text("a"))),
+			diagnostics: []latex.Diagnostic{
+				{Offset: 36, Message: "command \\x is already defined"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			parser := latex.NewParser(strings.NewReader(tc.input))
+
+			got, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			if !cmp.Equal(tc.output, got) {
+				t.Errorf("Tree does not match:\n%s\n", cmp.Diff(tc.output, got))
+			}
+
+			if !cmp.Equal(tc.diagnostics, parser.Diagnostics()) {
+				t.Errorf("Diagnostics do not match:\n%s\n", cmp.Diff(tc.diagnostics, parser.Diagnostics()))
+			}
+		})
+	}
+}
+
+func TestParser_TableValidation(t *testing.T) {
+	input := "\\begin{tabular}{|l|r|}\n\\multicolumn{2}{c}{Title} \\\\ \\hline\na & b \\\\\na & b & c \\\\\na\n\\end{tabular}"
+
+	parser := latex.NewParser(strings.NewReader(input), latex.WithTableValidation())
+	if _, err := parser.Parse(); err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	want := []latex.Diagnostic{{Offset: 81, Message: "row has 3 cells, but colspec defines 2 columns"}}
+	got := parser.Diagnostics()
+
+	if !cmp.Equal(want, got) {
+		t.Errorf("Diagnostics do not match:\n%s\n", cmp.Diff(want, got))
+	}
+
+	// validation is disabled by default
+	parser = latex.NewParser(strings.NewReader(input))
+	if _, err := parser.Parse(); err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	if len(parser.Diagnostics()) != 0 {
+		t.Errorf("Diagnostics must be empty, got %v", parser.Diagnostics())
+	}
+}
+
+func TestParser_RecoveryLimit(t *testing.T) {
+	// malformed input which makes parser recover: invalid UTF-8 used to move tokenizer backwards, unclosed groups
+	// are closed at table cell boundary and the token is read again, stray closing tokens are skipped
+	inputs := []string{
+		"a\xffb\xfe\\\xff{\xff}",
+		"\\begin{tabular}{ll}{a & b \\\\ \\textbf{c & d\\end{tabular}",
+		"}}]]\\end{itemize}\\fi\\else",
+		"\\string\\begin{center}x\\end{center}\\begin{}\\end{}",
+		"$a \\begin{itemize}\\item{\\begin{center}",
+	}
+
+	for _, input := range inputs {
+		done := make(chan error, 1)
+
+		// limit of 0 makes parser fail at the first iteration which doesn't consume input
+		go func() {
+			_, err := latex.NewParser(strings.NewReader(input), latex.WithRecoveryLimit(0)).Parse()
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Parse must consume input on every iteration, got %v for %q", err, input)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Parse does not terminate for %q", input)
+		}
+	}
+}
+
+func TestParser_ReadArguments(t *testing.T) {
+	parser := latex.NewParser(strings.NewReader("[opt \\{x\\}]  {Hello \\textbf{world}}{a \\} b}{plain} rest"))
+
+	raw, ok, err := parser.ReadOptionalRaw()
+	if err != nil || !ok || raw != "opt {x}" {
+		t.Errorf("ReadOptionalRaw: got %q, %v, %v", raw, ok, err)
+	}
+
+	// no optional argument: nothing is consumed
+	if _, ok, err := parser.ReadOptional(); err != nil || ok {
+		t.Errorf("ReadOptional: got %v, %v, expected missing argument", ok, err)
+	}
+
+	children, ok, err := parser.ReadArgument()
+	if err != nil || !ok || len(children) != 2 || children[1].Data != "\\textbf" {
+		t.Errorf("ReadArgument: got %v, %v, %v", children, ok, err)
+	}
+
+	raw, ok, err = parser.ReadArgumentRaw()
+	if err != nil || !ok || raw != "a } b" {
+		t.Errorf("ReadArgumentRaw: got %q, %v, %v", raw, ok, err)
+	}
+
+	str, ok, err := parser.ReadArgumentString()
+	if err != nil || !ok || str != "plain" {
+		t.Errorf("ReadArgumentString: got %q, %v, %v", str, ok, err)
+	}
+
+	// no obligatory argument: leading whitespaces are skipped, but the rest is not consumed
+	if _, ok, err := parser.ReadArgumentString(); err != nil || ok {
+		t.Errorf("ReadArgumentString: got %v, %v, expected missing argument", ok, err)
+	}
+}
+
+func TestParser_SourceMap(t *testing.T) {
+	input := "\\begin{tabular}{ll}\n  a & b \\\\ \\hline\n  \\multicolumn{2}{c}{c}\n\\end{tabular}"
+
+	doc, err := latex.NewParser(strings.NewReader(input), latex.WithSourceMap()).Parse()
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	source := func(node *latex.Node) string {
+		return input[node.Span.Start:node.Span.End]
+	}
+
+	table := doc.Children[0]
+	if len(table.Children) != 3 {
+		t.Fatalf("Table must have 3 rows, got %d", len(table.Children))
+	}
+
+	want := []struct {
+		row   string
+		cells []string
+	}{
+		{row: "\n  a & b ", cells: []string{"\n  a ", " b "}},
+		{},
+		{row: "\\multicolumn{2}{c}{c}", cells: []string{"\\multicolumn{2}{c}{c}"}},
+	}
+
+	for index, row := range table.Children {
+		if row.Data != "\\row" {
+			continue
+		}
+
+		if got := source(row); got != want[index].row {
+			t.Errorf("Row %d span does not match: want %#v, got %#v", index, want[index].row, got)
+		}
+
+		for cell, node := range row.Children {
+			if got := source(node); got != want[index].cells[cell] {
+				t.Errorf("Cell %d:%d span does not match: want %#v, got %#v", index, cell, want[index].cells[cell], got)
+			}
+		}
+	}
+
+	// spans are not recorded by default
+	doc, err = latex.NewParser(strings.NewReader(input)).Parse()
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	if span := doc.Children[0].Children[0].Span; span != (latex.Span{}) {
+		t.Errorf("Span must be empty, got %v", span)
+	}
+}
+
+func TestParser_SourceMapNodes(t *testing.T) {
+	input := "Some \\textbf{bold} text\n\n\\section{Title}\nand $x^2$."
+
+	doc, err := latex.NewParser(strings.NewReader(input), latex.WithSourceMap()).Parse()
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	var got []string
+	var walk func(node *latex.Node)
+	walk = func(node *latex.Node) {
+		// content of verbatim nodes (like math) is not recorded
+		if node.Span != (latex.Span{}) {
+			got = append(got, input[node.Span.Start:node.Span.End])
+		}
+
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+
+	walk(doc)
+
+	want := []string{
+		"Some \\textbf{bold} text\n",
+		"Some ",
+		"\\textbf{bold}",
+		"bold",
+		" text\n",
+		"\\section{Title}\nand $x^2$.",
+		"\\section{Title}",
+		"Title",
+		"\nand ",
+		"$x^2$",
+		".",
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Errorf("Spans do not match:\n%s\n", cmp.Diff(want, got))
+	}
+}
+
+func TestParser_KeepEnvironmentDefinitions(t *testing.T) {
+	input := "\\newenvironment{note}[1][Note]{\\textbf{#1:} }{}\\begin{note}[Tip]Text\\end{note}"
+
+	doc, err := latex.NewParser(strings.NewReader(input), latex.WithKeepEnvironmentDefinitions()).Parse()
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	want := &latex.Node{Kind: latex.DocumentKind, Children: []*latex.Node{
+		{Kind: latex.ElementKind, Data: "\\newenvironment", Parameters: map[string]string{"name": "note", "args": "1", "default": "Note", "begin": "\\textbf{#1:} ", "end": ""}},
+		{Kind: latex.ElementKind, Data: "note", Parameters: map[string]string{"options": "Tip"}, Children: []*latex.Node{
+			{Kind: latex.ElementKind, Data: "\\par", Children: []*latex.Node{{Kind: latex.TextKind, Data: "Text"}}},
+		}},
+	}}
+
+	if !cmp.Equal(want, doc) {
+		t.Errorf("Tree does not match:\n%s\n", cmp.Diff(want, doc))
+	}
+
+	// kept definition renders back as written
+	if got := latex.Source(doc); got != input {
+		t.Errorf("Source does not match input:\nwant: %q\n got: %q", input, got)
+	}
+}
+
+func TestParser_SoftHyphens(t *testing.T) {
+	input := "hy\\-phen \\- and/or"
+
+	doc, err := latex.NewParser(strings.NewReader(input), latex.WithSoftHyphens()).Parse()
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	want := &latex.Node{Kind: latex.DocumentKind, Children: []*latex.Node{
+		{Kind: latex.ElementKind, Data: "\\par", Children: []*latex.Node{{Kind: latex.TextKind, Data: "hy\u00ADphen \u00AD and/or"}}},
+	}}
+
+	if !cmp.Equal(want, doc) {
+		t.Errorf("Tree does not match:\n%s\n", cmp.Diff(want, doc))
+	}
+}
+
+func TestParser_TieAsNBSP(t *testing.T) {
+	tt := []struct {
+		name   string
+		option latex.ParserOption
+		output string
+	}{
+		{name: "default", output: "Fig." + nbsp + "1"},
+		{name: "enabled", option: latex.WithTieAsNBSP(true), output: "Fig." + nbsp + "1"},
+		{name: "disabled", option: latex.WithTieAsNBSP(false), output: "Fig.~1"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var opts []latex.ParserOption
+			if tc.option != nil {
+				opts = append(opts, tc.option)
+			}
+
+			doc, err := latex.NewParser(strings.NewReader("Fig.~1"), opts...).Parse()
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			want := &latex.Node{Kind: latex.DocumentKind, Children: []*latex.Node{
+				{Kind: latex.ElementKind, Data: "\\par", Children: []*latex.Node{{Kind: latex.TextKind, Data: tc.output}}},
+			}}
+
+			if !cmp.Equal(want, doc) {
+				t.Errorf("Tree does not match:\n%s\n", cmp.Diff(want, doc))
+			}
+		})
+	}
+}
+
+func TestParser_Loader(t *testing.T) {
+	input := "See code:\n\\verbatiminput{main.cpp}"
+
+	files := map[string]string{"main.cpp": "int main() {\n    return 0;\n}\n"}
+	loader := func(name string) (string, error) {
+		if content, ok := files[name]; ok {
+			return content, nil
+		}
+
+		return "", errors.New("file not found")
+	}
+
+	doc, err := latex.NewStrictParser(strings.NewReader(input), latex.WithLoader(loader)).Parse()
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	want := &latex.Node{Kind: latex.DocumentKind, Children: []*latex.Node{
+		{Kind: latex.ElementKind, Data: "\\par", Children: []*latex.Node{{Kind: latex.TextKind, Data: "See code:\n"}}},
+		{Kind: latex.ElementKind, Data: "verbatim", Parameters: map[string]string{"src": "main.cpp"}, Children: []*latex.Node{{Kind: latex.TextKind, Data: files["main.cpp"]}}},
+	}}
+
+	if !cmp.Equal(want, doc) {
+		t.Errorf("Tree does not match:\n%s\n", cmp.Diff(want, doc))
+	}
+
+	// without loader file is kept as a reference
+	doc, err = latex.NewStrictParser(strings.NewReader(input)).Parse()
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	want.Children[1] = &latex.Node{Kind: latex.ElementKind, Data: "\\verbatiminput", Parameters: map[string]string{"src": "main.cpp"}}
+	if !cmp.Equal(want, doc) {
+		t.Errorf("Tree does not match:\n%s\n", cmp.Diff(want, doc))
+	}
+
+	// files which can't be loaded are errors
+	if _, err := latex.NewStrictParser(strings.NewReader("\\verbatiminput{missing.cpp}"), latex.WithLoader(loader)).Parse(); err == nil {
+		t.Error("Parse must fail when file can't be loaded")
+	}
+}
+
+func TestParser_RecursiveEnvironment(t *testing.T) {
+	input := "\\newenvironment{loop}{\\begin{loop}}{\\end{loop}}\\begin{loop}x\\end{loop}"
+
+	parser := latex.NewParser(strings.NewReader(input))
+	if _, err := parser.Parse(); err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	// errors inside expanded environment point to the environment
+	want := latex.Diagnostic{Offset: 47, Message: "environment loop is nested too deep"}
+	if diagnostics := parser.Diagnostics(); len(diagnostics) == 0 || diagnostics[0] != want {
+		t.Errorf("Diagnostics do not match: want %v first, got %v", want, diagnostics)
+	}
+
+	if _, err := latex.NewStrictParser(strings.NewReader(input)).Parse(); err == nil {
+		t.Errorf("Strict parser is expected to fail on recursive environment")
+	}
+}
+
+func TestParser_ExpansionLimit(t *testing.T) {
+	// each expansion of x contains 10 more x, so the document would expand to 10^10 environments
+	input := "\\newenvironment{x}{" + strings.Repeat("\\begin{x}\\end{x}", 10) + "}{}\\begin{x}\\end{x}"
+
+	// parser can't recover from exceeded limit even in non-strict mode
+	_, err := latex.NewParser(strings.NewReader(input)).Parse()
+	if want := "environment x: custom environments expand to more than 1048576 bytes"; err == nil || err.Error() != want {
+		t.Errorf("Parse is expected to fail with %q, got %v", want, err)
+	}
+}
+
+func TestParser_LenientMath(t *testing.T) {
+	doc := func(children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.DocumentKind, Children: children}
+	}
+
+	text := func(t string) *latex.Node {
+		return &latex.Node{Kind: latex.TextKind, Data: t}
+	}
+
+	par := func(children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.ElementKind, Data: "\\par", Children: children}
+	}
+
+	element := func(command string, children ...*latex.Node) *latex.Node {
+		return &latex.Node{Kind: latex.ElementKind, Data: command, Children: children}
+	}
+
+	tt := []struct {
+		name    string
+		input   string
+		lenient *latex.Node
+		regular *latex.Node
+	}{
+		{
+			name:    "inline math",
+			input:   "Sum $a+b",
+			lenient: doc(par(text("Sum "), element("$", text("a+b")))),
+			regular: doc(par(text("Sum $a+b"))),
+		},
+		{
+			name:    "block math",
+			input:   "$$a+b$",
+			lenient: doc(element("$$", text("a+b"))),
+			regular: doc(par(text("$$a+b$"))),
+		},
+		{
+			name:    "closed math",
+			input:   "$a$ and $b",
+			lenient: doc(par(element("$", text("a")), text(" and "), element("$", text("b")))),
+			regular: doc(par(element("$", text("a")), text(" and $b"))),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := latex.NewParser(strings.NewReader(tc.input), latex.WithLenientMath()).Parse()
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			if !cmp.Equal(tc.lenient, got) {
+				t.Errorf("Tree does not match in lenient mode:\n%s", cmp.Diff(tc.lenient, got))
+			}
+
+			got, err = latex.NewParser(strings.NewReader(tc.input)).Parse()
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			if !cmp.Equal(tc.regular, got) {
+				t.Errorf("Tree does not match by default:\n%s", cmp.Diff(tc.regular, got))
+			}
+		})
+	}
+}
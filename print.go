@@ -0,0 +1,79 @@
+package latex
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// prettyTextLimit is maximum number of characters of text node printed by PrettyPrint
+const prettyTextLimit = 40
+
+// PrettyPrint writes node tree in human-readable form: one node per line, indented according to the depth, with
+// node kind, data and parameters. Long text is truncated. It's intended for debugging and logs.
+//
+// Example output:
+//
+//	document
+//	  element "\\par"
+//	    text "Hello, "
+//	    element "\\textbf"
+//	      text "world"
+//	  element "tabular" {colspec="ll"}
+func PrettyPrint(w io.Writer, node *Node) error {
+	return prettyPrint(w, node, 0)
+}
+
+func prettyPrint(w io.Writer, node *Node, depth int) error {
+	line := strings.Repeat("  ", depth)
+
+	switch node.Kind {
+	case TextKind:
+		line += "text " + fmt.Sprintf("%q", truncate(node.Data, prettyTextLimit))
+	case DocumentKind:
+		line += "document"
+	case ElementKind:
+		line += "element " + fmt.Sprintf("%q", node.Data)
+	default:
+		line += fmt.Sprintf("kind(%d) %q", node.Kind, node.Data)
+	}
+
+	if len(node.Parameters) > 0 {
+		keys := make([]string, 0, len(node.Parameters))
+		for key := range node.Parameters {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		params := make([]string, 0, len(keys))
+		for _, key := range keys {
+			params = append(params, fmt.Sprintf("%s=%q", key, node.Parameters[key]))
+		}
+
+		line += " {" + strings.Join(params, ", ") + "}"
+	}
+
+	if _, err := fmt.Fprintln(w, line); err != nil {
+		return err
+	}
+
+	for _, child := range node.Children {
+		if err := prettyPrint(w, child, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// truncate cuts text to given number of characters, adding ellipsis if anything was cut
+func truncate(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	return string([]rune(text)[:limit]) + "…"
+}
@@ -0,0 +1,44 @@
+package latex_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestPrettyPrint(t *testing.T) {
+	input := "Hello, \\textbf{world}!\n\n\\begin{tabular}{ll}\na & b\n\\end{tabular}\nThis paragraph is long enough to be truncated when printed."
+
+	doc, err := latex.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	want := "document\n" +
+		"  element \"\\\\par\"\n" +
+		"    text \"Hello, \"\n" +
+		"    element \"\\\\textbf\"\n" +
+		"      text \"world\"\n" +
+		"    text \"!\\n\"\n" +
+		"  element \"tabular\" {colspec=\"ll\"}\n" +
+		"    element \"\\\\row\"\n" +
+		"      element \"\\\\cell\"\n" +
+		"        element \"\\\\par\"\n" +
+		"          text \"\\na \"\n" +
+		"      element \"\\\\cell\"\n" +
+		"        element \"\\\\par\"\n" +
+		"          text \" b\\n\"\n" +
+		"  element \"\\\\par\"\n" +
+		"    text \"This paragraph is long enough to be trun…\"\n"
+
+	b := bytes.NewBuffer(nil)
+	if err := latex.PrettyPrint(b, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.String() != want {
+		t.Errorf("Printed tree does not match:\nwant:\n%s\n got:\n%s", want, b.String())
+	}
+}
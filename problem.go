@@ -0,0 +1,174 @@
+package latex
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var quantity = regexp.MustCompile("^\\s*([0-9]*\\.?[0-9]+)\\s*([a-zA-Z]*)\\s*$")
+
+// Problem describes metadata of the problem environment
+type Problem struct {
+	Title       string
+	InputFile   string
+	OutputFile  string
+	TimeLimit   time.Duration
+	MemoryLimit int64 // memory limit in bytes
+
+	// sections of the statement, populated by ParseProblem
+	Statement    []*Node // content preceding the first section marker
+	InputFormat  []*Node // content following \InputFile or \InputData
+	OutputFormat []*Node // content following \OutputFile
+	Interaction  []*Node // content following \Interaction
+	Scoring      []*Node // content following \Scoring
+	Notes        []*Node // content following \Note
+}
+
+// ParseProblem extracts metadata and statement sections from the problem environment. Content following section
+// markers (\InputFile, \OutputFile, \Note etc.) up to the next marker is assigned to the corresponding section.
+// Examples are not included in any section, use Examples to extract them.
+func ParseProblem(doc *Node) (*Problem, error) {
+	problem, err := ProblemMeta(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := map[string]*[]*Node{
+		"\\InputFile":   &problem.InputFormat,
+		"\\InputData":   &problem.InputFormat,
+		"\\OutputFile":  &problem.OutputFormat,
+		"\\Interaction": &problem.Interaction,
+		"\\Scoring":     &problem.Scoring,
+		"\\Note":        &problem.Notes,
+	}
+
+	section := &problem.Statement
+	for _, child := range findProblem(doc).Children {
+		if child.Kind == ElementKind && sectionMarkers[child.Data] {
+			section = sections[child.Data]
+			if section != nil {
+				// marker may already own its content if document was grouped with GroupSections
+				*section = append(*section, child.Children...)
+			}
+
+			continue
+		}
+
+		if section != nil {
+			*section = append(*section, child)
+		}
+	}
+
+	return problem, nil
+}
+
+// ProblemMeta extracts metadata from the problem environment. The node can be problem environment itself or any node
+// containing it, in which case first problem environment is used.
+func ProblemMeta(node *Node) (*Problem, error) {
+	env := findProblem(node)
+	if env == nil {
+		return nil, errors.New("problem environment is not found")
+	}
+
+	problem := &Problem{
+		Title:      env.Parameters["title"],
+		InputFile:  env.Parameters["input"],
+		OutputFile: env.Parameters["output"],
+	}
+
+	if v := env.Parameters["time_limit"]; v != "" {
+		limit, err := ParseTimeLimit(v)
+		if err != nil {
+			return nil, err
+		}
+
+		problem.TimeLimit = limit
+	}
+
+	if v := env.Parameters["memory_limit"]; v != "" {
+		limit, err := ParseMemoryLimit(v)
+		if err != nil {
+			return nil, err
+		}
+
+		problem.MemoryLimit = limit
+	}
+
+	return problem, nil
+}
+
+// findProblem returns first problem environment in the tree
+func findProblem(node *Node) *Node {
+	if node.Kind == ElementKind && node.Data == "problem" {
+		return node
+	}
+
+	for _, child := range node.Children {
+		if env := findProblem(child); env != nil {
+			return env
+		}
+	}
+
+	return nil
+}
+
+// ParseTimeLimit parses time limit like "1 second" or "1500 milliseconds", a number without units is treated as seconds
+func ParseTimeLimit(raw string) (time.Duration, error) {
+	value, unit, err := parseQuantity(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time limit %#v: %w", raw, err)
+	}
+
+	switch unit {
+	case "ms", "msec", "msecs", "millisecond", "milliseconds":
+		return time.Duration(value * float64(time.Millisecond)), nil
+	case "", "s", "sec", "secs", "second", "seconds":
+		return time.Duration(value * float64(time.Second)), nil
+	case "min", "mins", "minute", "minutes":
+		return time.Duration(value * float64(time.Minute)), nil
+	default:
+		return 0, fmt.Errorf("invalid time limit %#v: unit %#v is not supported", raw, unit)
+	}
+}
+
+// ParseMemoryLimit parses memory limit like "256 megabytes" or "64 MiB" and returns number of bytes, a number without
+// units is treated as megabytes. Following competitive programming tradition kilobytes, megabytes and gigabytes are
+// treated as powers of 1024, same as kibibytes, mebibytes and gibibytes.
+func ParseMemoryLimit(raw string) (int64, error) {
+	value, unit, err := parseQuantity(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory limit %#v: %w", raw, err)
+	}
+
+	switch unit {
+	case "b", "byte", "bytes":
+		return int64(value), nil
+	case "k", "kb", "kib", "kilobyte", "kilobytes", "kibibyte", "kibibytes":
+		return int64(value * (1 << 10)), nil
+	case "", "m", "mb", "mib", "megabyte", "megabytes", "mebibyte", "mebibytes":
+		return int64(value * (1 << 20)), nil
+	case "g", "gb", "gib", "gigabyte", "gigabytes", "gibibyte", "gibibytes":
+		return int64(value * (1 << 30)), nil
+	default:
+		return 0, fmt.Errorf("invalid memory limit %#v: unit %#v is not supported", raw, unit)
+	}
+}
+
+// parseQuantity splits value like "1.5 seconds" into a number and lowercase unit
+func parseQuantity(raw string) (float64, string, error) {
+	match := quantity.FindStringSubmatch(raw)
+	if len(match) == 0 {
+		return 0, "", errors.New("value must be a number followed by units")
+	}
+
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return value, strings.ToLower(match[2]), nil
+}
@@ -0,0 +1,155 @@
+package latex_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestProblemMeta(t *testing.T) {
+	input := "\\begin{problem}{Шахівниця}{standard input}{standard output}{1 second}{256 megabytes} \n \nДано шахівницю $8\\times 8$. \\end{problem}"
+
+	doc, err := latex.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	got, err := latex.ProblemMeta(doc)
+	if err != nil {
+		t.Fatalf("Unable to extract problem metadata: %v", err)
+	}
+
+	want := &latex.Problem{
+		Title:       "Шахівниця",
+		InputFile:   "standard input",
+		OutputFile:  "standard output",
+		TimeLimit:   time.Second,
+		MemoryLimit: 256 * 1024 * 1024,
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Errorf("Problem metadata does not match:\n%s\n", cmp.Diff(want, got))
+	}
+}
+
+func TestParseProblem(t *testing.T) {
+	input := "\\begin{problem}{Sum}{standard input}{standard output}{2 seconds}{64 megabytes}Find $a+b$.\n\n\\InputFile\n\nTwo integers.\n\n\\OutputFile\n\nOne integer.\n\n\\Examples\n\n\\exmp{1 2}{3}\n\n\\Scoring\n\nSubtasks.\n\n\\Note\n\nBe careful.\n\\end{problem}"
+
+	doc, err := latex.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	par := func(text string) *latex.Node {
+		return &latex.Node{Kind: latex.ElementKind, Data: "\\par", Children: []*latex.Node{{Kind: latex.TextKind, Data: text}}}
+	}
+
+	want := &latex.Problem{
+		Title:        "Sum",
+		InputFile:    "standard input",
+		OutputFile:   "standard output",
+		TimeLimit:    2 * time.Second,
+		MemoryLimit:  64 * 1024 * 1024,
+		Statement:    []*latex.Node{{Kind: latex.ElementKind, Data: "\\par", Children: []*latex.Node{{Kind: latex.TextKind, Data: "Find "}, {Kind: latex.ElementKind, Data: "$", Children: []*latex.Node{{Kind: latex.TextKind, Data: "a+b"}}}, {Kind: latex.TextKind, Data: ".\n"}}}},
+		InputFormat:  []*latex.Node{par("Two integers.\n")},
+		OutputFormat: []*latex.Node{par("One integer.\n")},
+		Scoring:      []*latex.Node{par("Subtasks.\n")},
+		Notes:        []*latex.Node{par("Be careful.\n")},
+	}
+
+	for name, doc := range map[string]*latex.Node{"plain": doc, "grouped": latex.GroupSections(doc)} {
+		t.Run(name, func(t *testing.T) {
+			got, err := latex.ParseProblem(doc)
+			if err != nil {
+				t.Fatalf("Unable to parse problem: %v", err)
+			}
+
+			if !cmp.Equal(want, got) {
+				t.Errorf("Problem does not match:\n%s\n", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestProblemMeta_Errors(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+	}{
+		{name: "no problem environment", input: "just text"},
+		{name: "invalid time limit", input: "\\begin{problem}{Title}{in}{out}{forever}{256 megabytes}\\end{problem}"},
+		{name: "invalid memory limit", input: "\\begin{problem}{Title}{in}{out}{1 second}{256 parsecs}\\end{problem}"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := latex.Parse(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			if _, err := latex.ProblemMeta(doc); err == nil {
+				t.Errorf("ProblemMeta must return an error")
+			}
+		})
+	}
+}
+
+func TestParseTimeLimit(t *testing.T) {
+	tt := []struct {
+		input  string
+		output time.Duration
+	}{
+		{input: "1 second", output: time.Second},
+		{input: "2 seconds", output: 2 * time.Second},
+		{input: "1500 milliseconds", output: 1500 * time.Millisecond},
+		{input: "250ms", output: 250 * time.Millisecond},
+		{input: "0.5 sec", output: 500 * time.Millisecond},
+		{input: "3", output: 3 * time.Second},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := latex.ParseTimeLimit(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.output {
+				t.Errorf("Time limit does not match: want %v, got %v", tc.output, got)
+			}
+		})
+	}
+}
+
+func TestParseMemoryLimit(t *testing.T) {
+	tt := []struct {
+		input  string
+		output int64
+	}{
+		{input: "256 megabytes", output: 256 << 20},
+		{input: "64 MiB", output: 64 << 20},
+		{input: "512 mebibytes", output: 512 << 20},
+		{input: "65536 KB", output: 64 << 20},
+		{input: "1 GB", output: 1 << 30},
+		{input: "1024 bytes", output: 1024},
+		{input: "128", output: 128 << 20},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := latex.ParseMemoryLimit(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.output {
+				t.Errorf("Memory limit does not match: want %v, got %v", tc.output, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package latex
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ResolveReferences walks document and resolves cross-references: each \\bibitem gets a "number" parameter
+// based on its position in the bibliography and each \\cite gets a "numbers" parameter, a comma separated list of
+// numbers of cited items. Keys which can not be resolved are numbered as "?".
+//
+// Each \\nameref gets a "name" parameter with the title of the section where referenced \\label is placed.
+func ResolveReferences(doc *Node) {
+	numbers := map[string]string{}
+	names := map[string]string{}
+	section := ""
+	count := 0
+
+	walk(doc, func(node *Node) {
+		if node.Kind != ElementKind {
+			return
+		}
+
+		switch node.Data {
+		case "\\chapter", "\\section", "\\subsection", "\\subsubsection":
+			section = String(node)
+		case "\\label":
+			names[node.Parameters["label"]] = section
+		case "\\bibitem":
+			count++
+
+			if node.Parameters == nil {
+				node.Parameters = map[string]string{}
+			}
+
+			node.Parameters["number"] = strconv.Itoa(count)
+			numbers[node.Parameters["key"]] = node.Parameters["number"]
+		}
+	})
+
+	walk(doc, func(node *Node) {
+		if node.Kind == ElementKind && node.Data == "\\nameref" {
+			if name, ok := names[node.Parameters["label"]]; ok {
+				node.Parameters["name"] = name
+			}
+		}
+
+		if node.Kind != ElementKind || node.Data != "\\cite" {
+			return
+		}
+
+		var resolved []string
+		for _, key := range strings.Split(node.Parameters["keys"], ",") {
+			if n, ok := numbers[key]; ok {
+				resolved = append(resolved, n)
+			} else {
+				resolved = append(resolved, "?")
+			}
+		}
+
+		node.Parameters["numbers"] = strings.Join(resolved, ",")
+	})
+}
+
+// walk calls visit for node and all its descendants in document order
+func walk(node *Node, visit func(*Node)) {
+	visit(node)
+
+	for _, child := range node.Children {
+		walk(child, visit)
+	}
+}
@@ -0,0 +1,72 @@
+package latex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestResolveReferences(t *testing.T) {
+	input := "See \\cite{knuth, lamport}, \\cite[p. 42]{knuth} and \\cite{unknown}.\n\n" +
+		"\\begin{thebibliography}{9}\n\\bibitem{lamport} Leslie Lamport.\n\\bibitem{knuth} Donald Knuth.\n\\end{thebibliography}"
+
+	doc, err := latex.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	latex.ResolveReferences(doc)
+
+	var got []map[string]string
+	for _, node := range doc.Children[0].Children {
+		if node.Data == "\\cite" {
+			got = append(got, node.Parameters)
+		}
+	}
+
+	for _, node := range doc.Children[1].Children {
+		got = append(got, node.Parameters)
+	}
+
+	want := []map[string]string{
+		{"keys": "knuth,lamport", "numbers": "2,1"},
+		{"keys": "knuth", "note": "p. 42", "numbers": "2"},
+		{"keys": "unknown", "numbers": "?"},
+		{"key": "lamport", "number": "1"},
+		{"key": "knuth", "number": "2"},
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Errorf("Resolved references do not match:\n%s\n", cmp.Diff(want, got))
+	}
+}
+
+func TestResolveReferences_Labels(t *testing.T) {
+	input := "See \\nameref{sec:details} and \\nameref{sec:unknown}.\n\n\\section{Intro}\n\n\\subsection{Details}\\label{sec:details}"
+
+	doc, err := latex.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	latex.ResolveReferences(doc)
+
+	var got []map[string]string
+	for _, node := range doc.Children[0].Children {
+		if node.Data == "\\nameref" {
+			got = append(got, node.Parameters)
+		}
+	}
+
+	want := []map[string]string{
+		{"label": "sec:details", "name": "Details"},
+		{"label": "sec:unknown"},
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Errorf("Resolved references do not match:\n%s\n", cmp.Diff(want, got))
+	}
+}
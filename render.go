@@ -4,36 +4,196 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
 )
 
-func Render(w io.Writer, node *Node) error {
-	return render(w, node)
+// RenderOption configures rendering
+type RenderOption func(*renderOptions)
+
+type renderOptions struct {
+	labels    map[string]string
+	exact     bool
+	tabWidth  int
+	gobble    bool
+	safe      bool
+	sanitizer URLSanitizer
+	dollars   bool
+	inline    [2]string // delimiters of inline formulas in HTML
+	block     [2]string // delimiters of block formulas in HTML
 }
 
-func render(w io.Writer, node *Node) error {
+// WithExactWhitespace disables canonical formatting (like blank lines after paragraphs and environments), so
+// whitespaces captured in text nodes are reproduced as is
+func WithExactWhitespace() RenderOption {
+	return func(o *renderOptions) {
+		o.exact = true
+	}
+}
+
+// WithTabWidth sets width of tab stops used to expand tabs in verbatim blocks (verbatim and lstlisting) rendered as
+// HTML, zero or negative width keeps tabs as is. Default width is 8. LaTeX output is not affected.
+func WithTabWidth(n int) RenderOption {
+	return func(o *renderOptions) {
+		o.tabWidth = n
+	}
+}
+
+// WithGobbleIndent removes indentation common to all lines of verbatim blocks (verbatim and lstlisting) rendered as
+// HTML, relative indentation of lines is preserved. It's useful when code is indented to match surrounding LaTeX.
+func WithGobbleIndent(gobble bool) RenderOption {
+	return func(o *renderOptions) {
+		o.gobble = gobble
+	}
+}
+
+// WithSafeLinks normalizes links (\\url and \\href) rendered as HTML: scheme-relative URLs and bare domains get
+// https:// prefix. LaTeX output is not affected.
+func WithSafeLinks() RenderOption {
+	return func(o *renderOptions) {
+		o.safe = true
+	}
+}
+
+// WithURLSanitizer sets sanitizer for targets of links (\\url and \\href) and sources of images and media rendered
+// as HTML, rejected links are rendered as plain text and rejected images are omitted. DefaultURLSanitizer is used
+// by default, nil disables sanitizing. LaTeX output is not affected.
+func WithURLSanitizer(sanitizer URLSanitizer) RenderOption {
+	return func(o *renderOptions) {
+		o.sanitizer = sanitizer
+	}
+}
+
+// WithDollarMath renders formulas written as math and displaymath environments with $ and $$ delimiters. HTML output
+// is not affected.
+func WithDollarMath() RenderOption {
+	return func(o *renderOptions) {
+		o.dollars = true
+	}
+}
+
+// WithMathDelimiters sets delimiters of inline and block formulas rendered as HTML, so output can be typeset by
+// different math engines: \\( and \\[ are used by default (MathJax), $ and $$ are common for KaTeX auto-render.
+// Delimiters apply only to formulas written with $ and $$, math environments (equation, align, cases, matrix etc.) are
+// rendered as is without delimiters, so math engine must be configured to process environments (MathJax does it by
+// default). LaTeX output is not affected.
+func WithMathDelimiters(inline, block [2]string) RenderOption {
+	return func(o *renderOptions) {
+		o.inline = inline
+		o.block = block
+	}
+}
+
+// Source renders node back to LaTeX preserving original whitespaces, it's a shortcut for Render with
+// WithExactWhitespace option. Render fails only when it's unable to write the output, which never happens with
+// in-memory buffer, so Source does not return an error.
+func Source(node *Node) string {
+	b := &strings.Builder{}
+
+	// strings.Builder never fails to write
+	_ = Render(b, node, WithExactWhitespace())
+
+	return b.String()
+}
+
+func Render(w io.Writer, node *Node, opts ...RenderOption) error {
+	r := &renderer{}
+	for _, opt := range opts {
+		opt(&r.renderOptions)
+	}
+
+	return r.render(w, node)
+}
+
+type renderer struct {
+	renderOptions
+	enumerates int // depth of nested enumerate environments, it selects counter set for numbered items
+}
+
+func (r *renderer) render(w io.Writer, node *Node) error {
 	switch node.Kind {
 	case DocumentKind:
-		return renderChildren(w, node)
+		return r.renderChildren(w, node)
 	case TextKind:
-		return renderText(w, node)
+		return r.renderText(w, node)
 	case ElementKind:
-		return renderElement(w, node)
+		return r.renderElement(w, node)
 	default:
 		return nil
 	}
 }
 
-func renderText(w io.Writer, node *Node) error {
-	value := node.Data
-	for f, t := range specials {
-		if t == "" {
-			continue
+// escaper replaces special characters in text in a single pass, so replaced text is never escaped again
+var escaper = newEscaper(specials)
+
+// newEscaper builds replacer from the rules, longer sequences go first, so they take precedence over their prefixes and
+// result does not depend on map iteration order
+func newEscaper(rules map[string]string) *strings.Replacer {
+	keys := make([]string, 0, len(rules))
+	for f, t := range rules {
+		if t != "" {
+			keys = append(keys, f)
 		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+
+		return keys[i] < keys[j]
+	})
 
-		value = strings.ReplaceAll(value, f, t)
+	pairs := make([]string, 0, 2*len(keys))
+	for _, f := range keys {
+		pairs = append(pairs, f, rules[f])
 	}
 
+	return strings.NewReplacer(pairs...)
+}
+
+// urlEscaper escapes characters which end or change verbatim parameters, % and # are kept as is
+var urlEscaper = strings.NewReplacer("\\", "\\\\", "{", "\\{", "}", "\\}")
+
+// escapeURL escapes URL, so it's read back as is by \\url and \\href
+func escapeURL(url string) string {
+	return urlEscaper.Replace(url)
+}
+
+// optionEscaper escapes characters unescaped when optional parameter is read in verbatim mode (see escSeq)
+var optionEscaper = strings.NewReplacer("\\", "\\\\", "{", "\\{", "}", "\\}", "[", "\\[", "]", "\\]")
+
+// escapeOption escapes value of optional parameter, so it's read back as is
+func escapeOption(value string) string {
+	return optionEscaper.Replace(value)
+}
+
+// ligatures are characters which form a different symbol when doubled (like "--" or "<<")
+const ligatures = "-<>'"
+
+// escape converts text to LaTeX, special characters are escaped and doubled ligature characters are separated by
+// an empty group, so text is parsed back as is
+func escape(text string) string {
+	b := &strings.Builder{}
+
+	start := 0
+	for i := 1; i < len(text); i++ {
+		if text[i] == text[i-1] && strings.IndexByte(ligatures, text[i]) >= 0 {
+			b.WriteString(escaper.Replace(text[start:i]))
+			b.WriteString("{}")
+			start = i
+		}
+	}
+
+	b.WriteString(escaper.Replace(text[start:]))
+
+	return b.String()
+}
+
+func (r *renderer) renderText(w io.Writer, node *Node) error {
+	value := escape(node.Data)
+
 	//for f, t := range replacements {
 	//	if t == "" || t == " " {
 	//		continue
@@ -46,7 +206,7 @@ func renderText(w io.Writer, node *Node) error {
 	return err
 }
 
-func renderVerbatim(w io.Writer, node *Node) error {
+func (r *renderer) renderVerbatim(w io.Writer, node *Node) error {
 	if node.Kind == TextKind {
 		if _, err := fmt.Fprint(w, node.Data); err != nil {
 			return err
@@ -54,7 +214,7 @@ func renderVerbatim(w io.Writer, node *Node) error {
 	}
 
 	for _, child := range node.Children {
-		if err := renderVerbatim(w, child); err != nil {
+		if err := r.renderVerbatim(w, child); err != nil {
 			return err
 		}
 	}
@@ -62,9 +222,16 @@ func renderVerbatim(w io.Writer, node *Node) error {
 	return nil
 }
 
-func renderChildren(w io.Writer, node *Node) error {
-	for _, child := range node.Children {
-		if err := render(w, child); err != nil {
+func (r *renderer) renderChildren(w io.Writer, node *Node) error {
+	for index, child := range node.Children {
+		// paragraphs are separated by an empty line which is not captured in text nodes
+		if r.exact && index > 0 && isParagraph(child) && isParagraph(node.Children[index-1]) {
+			if _, err := fmt.Fprint(w, "\n"); err != nil {
+				return err
+			}
+		}
+
+		if err := r.render(w, child); err != nil {
 			return err
 		}
 	}
@@ -72,12 +239,12 @@ func renderChildren(w io.Writer, node *Node) error {
 	return nil
 }
 
-func renderChildrenAndWrap(node *Node, w io.Writer, prefix, suffix string) error {
+func (r *renderer) renderChildrenAndWrap(node *Node, w io.Writer, prefix, suffix string) error {
 	if _, err := fmt.Fprint(w, prefix); err != nil {
 		return err
 	}
 
-	if err := renderChildren(w, node); err != nil {
+	if err := r.renderChildren(w, node); err != nil {
 		return err
 	}
 
@@ -88,12 +255,12 @@ func renderChildrenAndWrap(node *Node, w io.Writer, prefix, suffix string) error
 	return nil
 }
 
-func renderVerbatimAndWrap(node *Node, w io.Writer, prefix, suffix string) error {
+func (r *renderer) renderVerbatimAndWrap(node *Node, w io.Writer, prefix, suffix string) error {
 	if _, err := fmt.Fprint(w, prefix); err != nil {
 		return err
 	}
 
-	if err := renderVerbatim(w, node); err != nil {
+	if err := r.renderVerbatim(w, node); err != nil {
 		return err
 	}
 
@@ -104,61 +271,135 @@ func renderVerbatimAndWrap(node *Node, w io.Writer, prefix, suffix string) error
 	return nil
 }
 
-func renderElement(w io.Writer, node *Node) error {
+// canonical returns whitespaces used for canonical formatting, they are omitted when exact whitespaces are requested
+func (r *renderer) canonical(ws string) string {
+	if r.exact {
+		return ""
+	}
+
+	return ws
+}
+
+// options returns optional parameter of the node wrapped in [], or empty string if node has no options. Options are
+// escaped, so they are read back as is.
+func (r *renderer) options(node *Node) string {
+	if v, ok := node.Parameters["options"]; ok {
+		return "[" + escapeOption(v) + "]"
+	}
+
+	return ""
+}
+
+// isParagraph checks if node is a paragraph
+func isParagraph(node *Node) bool {
+	return node.Kind == ElementKind && node.Data == "\\par"
+}
+
+func (r *renderer) renderElement(w io.Writer, node *Node) error {
 	switch node.Data {
 	case "\\par":
-		return renderChildrenAndWrap(node, w, "", "\n\n")
+		return r.renderChildrenAndWrap(node, w, "", r.canonical("\n\n"))
 	case "\\\\", "\\\\*", "\\newline":
-		_, err := fmt.Fprint(w, node.Data+"\n")
+		space := "\n"
+		if v, ok := node.Parameters["space"]; ok && r.exact {
+			space = v
+		}
+
+		_, err := fmt.Fprint(w, node.Data+space)
 		return err
 
-	case "\\InputFile", "\\InputData", "\\OutputFile", "\\Note", "\\Scoring", "\\Interaction", "\\Example", "\\Examples":
+	case "\\appendix":
 		_, err := fmt.Fprint(w, node.Data, "\n\n")
 		return err
-	case "\\dots", "\\ldots", "\\cdots", "\\vdots", "\\ddots", "\\hskip", "\\vskip", "\\hline", "\\cline", "\\multicolumn", "\\vspace", "\\hspace":
+	case "\\InputFile", "\\InputData", "\\OutputFile", "\\Note", "\\Scoring", "\\Interaction", "\\Example", "\\Examples":
+		// markers grouped by GroupSections own the content of their section
+		return r.renderChildrenAndWrap(node, w, node.Data+"\n\n", "")
+	case "\\dots", "\\ldots", "\\cdots", "\\vdots", "\\ddots", "\\hskip", "\\vskip", "\\hline", "\\multicolumn", "\\vspace", "\\hspace":
 		_, err := fmt.Fprint(w, node.Data)
 		return err
+	case "\\slash":
+		// space after the command is ignored by LaTeX, but it keeps the command from merging with following text
+		_, err := fmt.Fprint(w, "\\slash ")
+		return err
+	case "\\cline":
+		_, err := fmt.Fprint(w, "\\cline{", node.Parameters["range"], "}")
+		return err
 	case "\\epigraph":
 		return nil
 	case "\\epigraph:text", "\\epigraph:source":
 		return nil
 	case "\\item":
-		return renderChildrenAndWrap(node, w, "\\item ", "")
+		prefix := "\\item "
+
+		// numbering changed by \\setcounter is restored the same way
+		if v, err := strconv.Atoi(node.Parameters["value"]); err == nil && r.enumerates > 0 {
+			prefix = "\\setcounter{" + enumerateCounter(r.enumerates-1) + "}{" + strconv.Itoa(v-1) + "}" + prefix
+		}
+
+		return r.renderChildrenAndWrap(node, w, prefix, "")
 	case "\\verb", "\\verb*":
 		delimiter := node.Parameters["delimiter"]
 		if delimiter == "" {
 			delimiter = "|"
 		}
 
-		return renderVerbatimAndWrap(node, w, node.Data+delimiter, delimiter)
+		return r.renderVerbatimAndWrap(node, w, node.Data+delimiter, delimiter)
 	case "verbatim":
-		return renderVerbatimAndWrap(node, w, "\\begin{verbatim}\n", "\\end{verbatim}")
+		return r.renderVerbatimAndWrap(node, w, "\\begin{verbatim}\n", "\\end{verbatim}")
+	case "array", "cases", "matrix", "pmatrix", "bmatrix", "Bmatrix", "vmatrix", "Vmatrix", "smallmatrix":
+		return r.renderVerbatimAndWrap(node, w, "\\begin{"+node.Data+"}", "\\end{"+node.Data+"}")
+	case "equation", "equation*", "align", "align*":
+		return r.renderVerbatimAndWrap(node, w, "\\begin{"+node.Data+"}", "\\end{"+node.Data+"}"+r.canonical("\n\n"))
 	case "lstlisting":
 		params := ""
 		if v := node.Parameters["options"]; v != "" {
 			params = "[" + v + "]"
 		}
 
-		return renderVerbatimAndWrap(node, w, "\\begin{verbatim}"+params+"\n", "\\end{verbatim}")
-	case "tabular":
+		return r.renderVerbatimAndWrap(node, w, "\\begin{lstlisting}"+params+"\n", "\\end{lstlisting}")
+	case "minted":
+		return r.renderVerbatimAndWrap(node, w, "\\begin{minted}"+r.options(node)+"{"+node.Parameters["language"]+"}\n", "\\end{minted}")
+	case "tabular", "tabular*", "tabularx":
 		colspec := ""
 		if v := node.Parameters["colspec"]; v != "" {
 			colspec = "{" + v + "}"
 		}
 
+		if v, ok := node.Parameters["width"]; ok {
+			colspec = "{" + v + "}" + colspec
+		}
+
 		var rows []string
+		rule := false // previous line is a rule, consequent rules (like double \\hline) are placed on the same line
 		for index, child := range node.Children {
-			if child.Kind == ElementKind && child.Data == "\\hline" {
-				rows = append(rows, "\\hline")
+			if child.Kind == ElementKind && (child.Data == "\\hline" || child.Data == "\\cline") {
+				buffer := bytes.NewBuffer(nil)
+				if err := r.render(buffer, child); err != nil {
+					return err
+				}
+
+				if rule {
+					rows[len(rows)-1] += buffer.String()
+				} else {
+					rows = append(rows, buffer.String())
+				}
+
+				rule = true
 				continue
 			}
 
+			rule = false
+
 			buffer := bytes.NewBuffer(nil)
-			if err := render(buffer, child); err != nil {
+			if err := r.render(buffer, child); err != nil {
 				return err
 			}
 
 			suffix := " \\\\"
+			if child.Parameters["nobreak"] == "true" {
+				suffix += "*"
+			}
+
 			if index == len(node.Children)-1 {
 				suffix = ""
 			}
@@ -166,17 +407,44 @@ func renderElement(w io.Writer, node *Node) error {
 			rows = append(rows, strings.TrimSpace(buffer.String())+suffix)
 		}
 
-		_, err := fmt.Fprint(w, "\\begin{tabular}"+colspec+"\n", strings.Join(rows, "\n"), "\n\\end{tabular}\n\n")
+		_, err := fmt.Fprint(w, "\\begin{"+node.Data+"}"+colspec+"\n", strings.Join(rows, "\n"), "\n\\end{"+node.Data+"}", r.canonical("\n\n"))
 		return err
-	case "itemize", "enumerate", "center", "example":
-		return renderChildrenAndWrap(node, w, "\\begin{"+node.Data+"}\n", "\\end{"+node.Data+"}\n\n")
+	case "itemize", "enumerate":
+		if node.Data == "enumerate" {
+			r.enumerates++
+			defer func() { r.enumerates-- }()
+		}
+
+		// content of lists before the first item is ignored, so line break is not captured in text nodes
+		return r.renderChildrenAndWrap(node, w, "\\begin{"+node.Data+"}"+r.options(node)+"\n", "\\end{"+node.Data+"}"+r.canonical("\n\n"))
+	case "multicols":
+		return r.renderChildrenAndWrap(node, w, "\\begin{multicols}{"+node.Parameters["columns"]+"}"+r.canonical("\n"), "\\end{multicols}"+r.canonical("\n\n"))
+	case "minipage", "subfigure":
+		prefix := "\\begin{" + node.Data + "}"
+		for _, key := range []string{"position", "height", "inner"} {
+			v, ok := node.Parameters[key]
+			if !ok {
+				break
+			}
+
+			prefix += "[" + v + "]"
+		}
+
+		return r.renderChildrenAndWrap(node, w, prefix+"{"+node.Parameters["width"]+"}"+r.canonical("\n"), "\\end{"+node.Data+"}"+r.canonical("\n\n"))
+	case "theorem", "lemma", "proof", "definition", "corollary", "remark":
+		name := ""
+		if v, ok := node.Parameters["name"]; ok {
+			name = "[" + escapeOption(v) + "]"
+		}
+
+		return r.renderChildrenAndWrap(node, w, "\\begin{"+node.Data+"}"+name+r.canonical("\n"), "\\end{"+node.Data+"}"+r.canonical("\n\n"))
 	case "{}":
-		return renderChildren(w, node)
+		return r.renderChildren(w, node)
 	case "\\row":
 		var cells []string
 		for _, child := range node.Children {
 			buffer := bytes.NewBuffer(nil)
-			if err := render(buffer, child); err != nil {
+			if err := r.render(buffer, child); err != nil {
 				return err
 			}
 
@@ -186,27 +454,139 @@ func renderElement(w io.Writer, node *Node) error {
 		_, err := fmt.Fprint(w, strings.Join(cells, " & "))
 		return err
 	case "\\cell":
-		return renderChildren(w, node)
-	case "$":
-		return renderVerbatimAndWrap(node, w, "$", "$")
-	case "$$":
-		return renderVerbatimAndWrap(node, w, "$$", "$$")
+		colspan, rowspan := node.Parameters["colspan"], node.Parameters["rowspan"]
+		if colspan == "" && rowspan == "" {
+			return r.renderChildren(w, node)
+		}
+
+		// cells spanning multiple columns or rows are wrapped back into commands which defined them
+		buffer := bytes.NewBuffer(nil)
+		if err := r.renderChildren(buffer, node); err != nil {
+			return err
+		}
+
+		content := strings.TrimSpace(buffer.String())
+		if rowspan != "" {
+			content = "\\multirow{" + rowspan + "}{" + node.Parameters["width"] + "}{" + content + "}"
+		}
+
+		if colspan != "" {
+			content = "\\multicolumn{" + colspan + "}{" + node.Parameters["align"] + "}{" + content + "}"
+		}
+
+		_, err := fmt.Fprint(w, content)
+		return err
+	case "$", "$$":
+		if env := node.Parameters["environment"]; env != "" && !r.dollars {
+			return r.renderVerbatimAndWrap(node, w, "\\begin{"+env+"}", "\\end{"+env+"}")
+		}
+
+		return r.renderVerbatimAndWrap(node, w, node.Data, node.Data)
 	case "%", "comment":
 		return nil
 	case "\\symbol":
 		return nil
-	case "\\underline", "\\emph", "\\sout", "\\textmd", "\\textbf", "\\textup", "\\textit", "\\textsl", "\\textsc", "\\textsf", "\\textrm", "\\bf", "\\it", "\\t", "\\tt", "\\texttt", "\\tiny", "\\scriptsize", "\\small", "\\normalsize", "\\large", "\\Large", "\\LARGE", "\\huge", "\\Huge", "\\section", "\\subsection", "\\subsubsection", "\\bfseries", "\\itshape":
+	case "\\underline", "\\emph", "\\sout", "\\textmd", "\\textbf", "\\textup", "\\textit", "\\textsl", "\\textsc", "\\textsf", "\\textrm", "\\bf", "\\it", "\\t", "\\tt", "\\texttt", "\\tiny", "\\scriptsize", "\\small", "\\normalsize", "\\large", "\\Large", "\\LARGE", "\\huge", "\\Huge", "\\section", "\\subsection", "\\subsubsection", "\\bfseries", "\\itshape", "\\st", "\\ul", "\\phantom", "\\hphantom", "\\vphantom", "\\textsuperscript", "\\textsubscript":
 		if _, err := fmt.Fprint(w, node.Data+"{"); err != nil {
 			return err
 		}
 
-		if err := renderChildren(w, node); err != nil {
+		if err := r.renderChildren(w, node); err != nil {
 			return err
 		}
 
 		_, err := fmt.Fprint(w, "}")
 		return err
 
+	case "\\phantomsection":
+		_, err := fmt.Fprint(w, node.Data)
+		return err
+	case "\\relax", "\\ignorespaces":
+		// no-op commands are kept only when source is reproduced exactly, space separates command from following text
+		if !r.exact {
+			return nil
+		}
+
+		_, err := fmt.Fprint(w, node.Data, " ")
+		return err
+	case "\\addcontentsline":
+		return r.renderChildrenAndWrap(node, w, "\\addcontentsline{"+node.Parameters["file"]+"}{"+node.Parameters["level"]+"}{", "}")
+	case "\\newenvironment", "\\renewenvironment":
+		params := ""
+		if v, ok := node.Parameters["args"]; ok {
+			params += "[" + v + "]"
+		}
+
+		if v, ok := node.Parameters["default"]; ok {
+			params += "[" + v + "]"
+		}
+
+		_, err := fmt.Fprint(w, node.Data, "{", node.Parameters["name"], "}", params, "{", node.Parameters["begin"], "}{", node.Parameters["end"], "}", r.canonical("\n"))
+		return err
+	case "\\lstset":
+		_, err := fmt.Fprint(w, "\\lstset{", node.Parameters["options"], "}", r.canonical("\n"))
+		return err
+	case "\\verbatiminput":
+		_, err := fmt.Fprint(w, "\\verbatiminput{", node.Parameters["src"], "}", r.canonical("\n\n"))
+		return err
+	case "\\documentclass", "\\usepackage":
+		params := ""
+		if opts, ok := node.Parameters["options"]; ok {
+			params = "[" + opts + "]"
+		}
+
+		_, err := fmt.Fprint(w, node.Data, params, "{", node.Parameters["name"], "}", r.canonical("\n"))
+		return err
+	case "\\label", "\\autoref", "\\nameref":
+		_, err := fmt.Fprint(w, node.Data, "{", node.Parameters["label"], "}")
+		return err
+	case "\\hyperref":
+		return r.renderChildrenAndWrap(node, w, "\\hyperref["+node.Parameters["label"]+"]{", "}")
+	case "\\raisebox":
+		prefix := "\\raisebox{" + node.Parameters["lift"] + "}"
+		if v, ok := node.Parameters["height"]; ok {
+			prefix += "[" + v + "]"
+		}
+
+		if v, ok := node.Parameters["depth"]; ok {
+			prefix += "[" + v + "]"
+		}
+
+		return r.renderChildrenAndWrap(node, w, prefix+"{", "}")
+	case "\\cite":
+		note := ""
+		if v := node.Parameters["note"]; v != "" {
+			note = "[" + v + "]"
+		}
+
+		_, err := fmt.Fprint(w, "\\cite", note, "{", node.Parameters["keys"], "}")
+		return err
+	case "thebibliography":
+		return r.renderChildrenAndWrap(node, w, "\\begin{thebibliography}{"+node.Parameters["widest"]+"}"+r.canonical("\n"), "\\end{thebibliography}"+r.canonical("\n\n"))
+	case "\\bibitem":
+		label := ""
+		if v, ok := node.Parameters["label"]; ok {
+			label = "[" + v + "]"
+		}
+
+		return r.renderChildrenAndWrap(node, w, "\\bibitem"+label+"{"+node.Parameters["key"]+"}", "")
+	case "\\caption":
+		short := ""
+		if v, ok := node.Parameters["short"]; ok {
+			short = "[" + escapeOption(v) + "]"
+		}
+
+		return r.renderChildrenAndWrap(node, w, "\\caption"+short+"{", "}")
+	case "\\showln":
+		return r.renderChildrenAndWrap(node, w, "\\showln{", "}")
+	case "\\hl":
+		params := ""
+		if v := node.Parameters["color"]; v != "" {
+			params = "[" + v + "]"
+		}
+
+		return r.renderChildrenAndWrap(node, w, "\\hl"+params+"{", "}")
+
 	case "\\includegraphics":
 		src, _ := node.Parameters["src"]
 		params := ""
@@ -215,25 +595,34 @@ func renderElement(w io.Writer, node *Node) error {
 			params = "[" + opts + "]"
 		}
 
-		_, err := fmt.Fprint(w, "\\includegraphics", params, "{", src, "}\n\n")
+		_, err := fmt.Fprint(w, "\\includegraphics", params, "{", src, "}", r.canonical("\n\n"))
 		return err
 
 	case "\\url":
-		_, err := fmt.Fprint(w, "\\url{", node.Parameters["href"], "}")
+		_, err := fmt.Fprint(w, "\\url{", escapeURL(node.Parameters["href"]), "}")
 		return err
 	case "\\href":
-		return renderChildrenAndWrap(node, w, "\\href{"+node.Parameters["href"]+"}{", "}")
+		return r.renderChildrenAndWrap(node, w, "\\href"+r.options(node)+"{"+escapeURL(node.Parameters["href"])+"}{", "}")
+	case "\\texorpdfstring":
+		return r.renderChildrenAndWrap(node, w, "\\texorpdfstring{", "}{"+node.Parameters["pdfstring"]+"}")
 	case "\\def":
 		return nil
 	case "\\exmp":
-		return nil
+		_, err := fmt.Fprint(w, "\\exmp{", node.Parameters["input"], "}{", node.Parameters["output"], "}", r.canonical("\n"))
+		return err
 	case "\\exmpfile":
-		return nil
+		_, err := fmt.Fprint(w, "\\exmpfile{", node.Parameters["input"], "}{", node.Parameters["output"], "}{", node.Parameters["name"], "}", r.canonical("\n"))
+		return err
 	case "\\user":
 		_, err := fmt.Fprint(w, "\\user{", node.Parameters["nickname"], "}")
 		return err
 
 	default:
+		// environments (center, figure, custom ones etc.) are rendered as is with their options
+		if node.Data != "" && !strings.HasPrefix(node.Data, "\\") {
+			return r.renderChildrenAndWrap(node, w, "\\begin{"+node.Data+"}"+r.options(node)+r.canonical("\n"), "\\end{"+node.Data+"}"+r.canonical("\n\n"))
+		}
+
 		return nil
 	}
 }
@@ -3,6 +3,7 @@ package latex_test
 import (
 	"bytes"
 	"github.com/eolymp/go-latex"
+	"github.com/google/go-cmp/cmp"
 	"strings"
 	"testing"
 )
@@ -95,6 +96,18 @@ func TestRender(t *testing.T) {
 				par(text(" bar")),
 			),
 		},
+		{
+			name:   "math environments",
+			render: "foo \\begin{math}a_i^2\\end{math} bar\n\n\\begin{displaymath}a_i^2\\end{displaymath}",
+			document: doc(
+				par(
+					text("foo "),
+					elementp("$", map[string]string{"environment": "math"}, text("a_i^2")),
+					text(" bar"),
+				),
+				elementp("$$", map[string]string{"environment": "displaymath"}, text("a_i^2")),
+			),
+		},
 		{
 			name:   "image",
 			render: "This is an image \n\n\\includegraphics[scale=1.5]{eolymp.png} some text after...",
@@ -278,9 +291,70 @@ func TestRender(t *testing.T) {
 				),
 			),
 		},
+		{
+			name:   "enumerate with label options",
+			render: "\\begin{enumerate}[(a)]\n\\item First\n\\end{enumerate}",
+			document: doc(
+				elementp("enumerate", map[string]string{"options": "(a)"},
+					element("\\item", par(text("First"))),
+				),
+			),
+		},
+		{
+			name:   "itemize with spacing options",
+			render: "\\begin{itemize}[noitemsep,leftmargin=*]\n\\item One\n\\item Two\n\\end{itemize}",
+			document: doc(
+				elementp("itemize", map[string]string{"options": "noitemsep,leftmargin=*"},
+					element("\\item", par(text("One"))),
+					element("\\item", par(text("Two"))),
+				),
+			),
+		},
+		{
+			name:   "minipage",
+			render: "\\begin{minipage}{0.5\\textwidth}\nLeft\n\n\\end{minipage}\n\n\\begin{minipage}[t][3cm]{4cm}\nRight\n\n\\end{minipage}",
+			document: doc(
+				elementp("minipage", map[string]string{"width": "0.5\\textwidth"}, par(text("Left"))),
+				elementp("minipage", map[string]string{"position": "t", "height": "3cm", "width": "4cm"}, par(text("Right"))),
+			),
+		},
+		{
+			name:   "multicols",
+			render: "\\begin{multicols}{3}\nText\n\n\\end{multicols}",
+			document: doc(
+				elementp("multicols", map[string]string{"columns": "3"}, par(text("Text"))),
+			),
+		},
+		{
+			name:   "flush environments",
+			render: "\\begin{flushleft}\nLeft\n\n\\end{flushleft}\n\n\\begin{flushright}\nRight\n\n\\end{flushright}",
+			document: doc(
+				element("flushleft", par(text("Left"))),
+				element("flushright", par(text("Right"))),
+			),
+		},
+		{
+			name:   "appendix",
+			render: "\\section{Intro}\n\n\\appendix\n\n\\section{Proofs}",
+			document: doc(
+				par(element("\\section", text("Intro"))),
+				element("\\appendix"),
+				par(element("\\section", text("Proofs"))),
+			),
+		},
+		{
+			name:   "center with leading line break",
+			render: "\\begin{center}\n\\\\\ntext\n\n\\end{center}",
+			document: doc(
+				element("center",
+					element("\\\\"),
+					par(text("text")),
+				),
+			),
+		},
 		{
 			name:   "cf23",
-			render: "Some C++ source code (auto-detecting and highlighting):\n\n\n\\begin{verbatim}\n#include <iostream>\nint main() {\n    int a, b;\n    std::cin >> a >> b;\n    std::cout << a + b << std::endl;\n}\n\\end{verbatim}",
+			render: "Some C++ source code (auto-detecting and highlighting):\n\n\n\\begin{lstlisting}\n#include <iostream>\nint main() {\n    int a, b;\n    std::cin >> a >> b;\n    std::cout << a + b << std::endl;\n}\n\\end{lstlisting}",
 			document: doc(
 				par(text("Some C++ source code (auto-detecting and highlighting):\n")),
 				element("lstlisting", text("#include <iostream>\nint main() {\n    int a, b;\n    std::cin >> a >> b;\n    std::cout << a + b << std::endl;\n}\n")),
@@ -288,7 +362,7 @@ func TestRender(t *testing.T) {
 		},
 		{
 			name:   "lstlisting with language",
-			render: "Some C++ source code (auto-detecting and highlighting):\n\n\n\\begin{verbatim}[language=C++]\n#include <iostream>\nint main() {\n    int a, b;\n    std::cin >> a >> b;\n    std::cout << a + b << std::endl;\n}\n\\end{verbatim}",
+			render: "Some C++ source code (auto-detecting and highlighting):\n\n\n\\begin{lstlisting}[language=C++]\n#include <iostream>\nint main() {\n    int a, b;\n    std::cin >> a >> b;\n    std::cout << a + b << std::endl;\n}\n\\end{lstlisting}",
 			document: doc(
 				par(text("Some C++ source code (auto-detecting and highlighting):\n")),
 				elementp("lstlisting", map[string]string{"options": "language=C++"}, text("#include <iostream>\nint main() {\n    int a, b;\n    std::cin >> a >> b;\n    std::cout << a + b << std::endl;\n}\n")),
@@ -312,6 +386,13 @@ func TestRender(t *testing.T) {
 				text("."),
 			)),
 		},
+		{
+			name:   "href with options",
+			render: "\\href[color=red]{https://eolymp.com/}{Eolymp}",
+			document: doc(par(
+				elementp("\\href", map[string]string{"href": "https://eolymp.com/", "options": "color=red"}, text("Eolymp")),
+			)),
+		},
 		{
 			name:   "cf26",
 			render: "\\begin{center}\n\n  This content is centered.\n\n\n  $abacaba$\n\n\n\\end{center}",
@@ -373,6 +454,79 @@ func TestRender(t *testing.T) {
 				),
 			),
 		},
+		{
+			name:   "table with target width",
+			render: "\\begin{tabularx}{0.8\\textwidth}{|l|X|}\na & b\n\\end{tabularx}",
+			document: doc(
+				elementp("tabularx", map[string]string{"colspec": "|l|X|", "width": "0.8\\textwidth"},
+					element("\\row",
+						element("\\cell", par(text(" a "))),
+						element("\\cell", par(text(" b "))),
+					),
+				),
+			),
+		},
+		{
+			name:   "table with leading and double rules",
+			render: "\\begin{tabular}{|l|l|}\n\\hline\\hline\na & b \\\\\n\\cline{1-2}\\hline\nc & d\n\\end{tabular}",
+			document: doc(
+				elementp("tabular", map[string]string{"colspec": "|l|l|"},
+					element("\\hline"),
+					element("\\hline"),
+					element("\\row",
+						element("\\cell", par(text("a "))),
+						element("\\cell", par(text(" b "))),
+					),
+					elementp("\\cline", map[string]string{"range": "1-2"}),
+					element("\\hline"),
+					element("\\row",
+						element("\\cell", par(text("c "))),
+						element("\\cell", par(text(" d"))),
+					),
+				),
+			),
+		},
+		{
+			name:   "table with non-breaking row break",
+			render: "\\begin{tabular}{ll}\na & b \\\\*\nc & d\n\\end{tabular}",
+			document: doc(
+				elementp("tabular", map[string]string{"colspec": "ll"},
+					elementp("\\row", map[string]string{"nobreak": "true"},
+						element("\\cell", par(text("a "))),
+						element("\\cell", par(text(" b "))),
+					),
+					element("\\row",
+						element("\\cell", par(text("c "))),
+						element("\\cell", par(text(" d"))),
+					),
+				),
+			),
+		},
+		{
+			name:   "caption with short form",
+			render: "\\caption[Short]{Long \\textbf{caption}} \\caption{Plain}",
+			document: doc(par(
+				elementp("\\caption", map[string]string{"short": "Short"}, text("Long "), element("\\textbf", text("caption"))),
+				text(" "),
+				element("\\caption", text("Plain")),
+			)),
+		},
+		{
+			name:   "subfigure",
+			render: "\\begin{subfigure}[b]{0.5\\textwidth}\n\\caption{A}\n\n\\end{subfigure}",
+			document: doc(
+				elementp("subfigure", map[string]string{"position": "b", "width": "0.5\\textwidth"},
+					par(element("\\caption", text("A"))),
+				),
+			),
+		},
+		{
+			name:   "verse",
+			render: "\\begin{verse}\nLine one \\\\\nLine two\n\n\\end{verse}",
+			document: doc(
+				element("verse", par(text("Line one "), element("\\\\"), text("Line two"))),
+			),
+		},
 		{
 			name:   "cf32",
 			render: "More complex table with borders:\n\n\n\\begin{tabular}{|l|c|r|}\n\\hline\nLeft aligned column & Centered column & Right aligned column \\\\\n\\hline\nText & Text & Text \\\\\n\\hline\n\\end{tabular}",
@@ -469,6 +623,21 @@ func TestRender(t *testing.T) {
 				par(text("In English statements use these double quotes. As for the long dashes"+nbsp+"— use these like that.")),
 			),
 		},
+		{
+			name:     "text superscript and subscript",
+			render:   "1\\textsuperscript{st} place, H\\textsubscript{2}O",
+			document: doc(par(text("1"), element("\\textsuperscript", text("st")), text(" place, H"), element("\\textsubscript", text("2")), text("O"))),
+		},
+		{
+			name:     "soft hyphen and slash",
+			render:   "hy\\-phen input\\slash output",
+			document: doc(par(text("hy\u00ADphen input"), element("\\slash"), text("output"))),
+		},
+		{
+			name:     "thin spaces",
+			render:   "5\\,km a\\;b\\:c",
+			document: doc(par(text("5\u2009km a\u2005b\u205fc"))),
+		},
 		//{
 		//	name:   "cf38",
 		//	render: "\\epigraph{\\it{Some inspirational citation...}}{--- Author of citation, \\it{Source}}\nLegend starts here...",
@@ -504,6 +673,55 @@ func TestRender(t *testing.T) {
 			render:   "\\begin{example}\n\nfoobar\n\n\\end{example}",
 			document: doc(element("example", par(text("\nfoobar")))),
 		},
+		{
+			name:     "theorem with name",
+			render:   "\\begin{theorem}[Pythagoras]\n\nFoo.\n\n\\end{theorem}",
+			document: doc(elementp("theorem", map[string]string{"name": "Pythagoras"}, par(text("\nFoo.")))),
+		},
+		{
+			name:     "texorpdfstring",
+			render:   "\\texorpdfstring{$\\alpha$}{alpha}",
+			document: doc(par(elementp("\\texorpdfstring", map[string]string{"pdfstring": "alpha"}, element("$", text("\\alpha"))))),
+		},
+		{
+			name:   "escaped options",
+			render: "\\begin{admonition}[title=\\{\\[a\\]\\\\\\}]\nText\n\\end{admonition}\n\n\\begin{theorem}[\\{b\\}]\n\n\\caption[\\[c\\]]{C} \\href[\\]]{https://eolymp.com}{d}\n\n\\end{theorem}\n\n\\begin{minted}[escapeinside=\\\\\\\\]{python}\nprint(1)\n\\end{minted}",
+			document: doc(
+				elementp("admonition", map[string]string{"options": "title={[a]\\}"}, par(text("Text"))),
+				elementp("theorem", map[string]string{"name": "{b}"}, par(
+					elementp("\\caption", map[string]string{"short": "[c]"}, text("C")),
+					text(" "),
+					elementp("\\href", map[string]string{"href": "https://eolymp.com", "options": "]"}, text("d")),
+				)),
+				elementp("minted", map[string]string{"language": "python", "options": "escapeinside=\\\\"}, text("print(1)\n")),
+			),
+		},
+		{
+			name:   "minted",
+			render: "\\begin{minted}[linenos]{python}\nprint(1)\n\\end{minted}",
+			document: doc(
+				elementp("minted", map[string]string{"language": "python", "options": "linenos"}, text("print(1)\n")),
+			),
+		},
+		{
+			name:   "lstset",
+			render: "\\lstset{language=C++,basicstyle={\\ttfamily}}",
+			document: doc(
+				elementp("\\lstset", map[string]string{"options": "language=C++,basicstyle={\\ttfamily}"}),
+			),
+		},
+		{
+			name:     "relax and ignorespaces",
+			render:   "ab c",
+			document: doc(par(text("a"), element("\\relax"), text("b "), element("\\ignorespaces"), text("c"))),
+		},
+		{
+			name:   "newenvironment",
+			render: "\\newenvironment{note}[1][Note]{\\textbf{#1:} }{}",
+			document: doc(
+				elementp("\\newenvironment", map[string]string{"name": "note", "args": "1", "default": "Note", "begin": "\\textbf{#1:} ", "end": ""}),
+			),
+		},
 		{
 			name:   "p10675",
 			render: "\\begin{center}\n\n\n\n\\includegraphics{https://static.eolymp.com/content/2c/2cb0e289dc31d026e2c5481852803fe3a0b8c38b.png}\\end{center}",
@@ -539,6 +757,16 @@ func TestRender(t *testing.T) {
 			render:   "\\includegraphics{https://foo.com/www.bar.com/wp-content/uploads/2021/02/4cbe8d_f1ed2800a49649848102c68fc5a66e53mv2.gif?fit=476%2C280&ssl=1}",
 			document: doc(elementp("\\includegraphics", map[string]string{"src": "https://foo.com/www.bar.com/wp-content/uploads/2021/02/4cbe8d_f1ed2800a49649848102c68fc5a66e53mv2.gif?fit=476%2C280&ssl=1"})),
 		},
+		{
+			name:     "url with percent",
+			render:   "\\url{https://example.com/?fit=476%2C280#top}",
+			document: doc(par(elementp("\\url", map[string]string{"href": "https://example.com/?fit=476%2C280#top"}))),
+		},
+		{
+			name:     "href with space and braces",
+			render:   "\\href{https://example.com/my file\\{1\\}.pdf}{file}",
+			document: doc(par(elementp("\\href", map[string]string{"href": "https://example.com/my file{1}.pdf"}, text("file")))),
+		},
 		//{
 		//	name:   "p12854",
 		//	render: "\\epigraph{Hello, and again, welcome to the Aperture Science Enrichment Center.}",
@@ -565,6 +793,73 @@ func TestRender(t *testing.T) {
 				text(" to be a judge of this"),
 			)),
 		},
+		{
+			name:   "soul formatting",
+			render: "\\st{struck} \\ul{underlined} \\hl{marked} \\hl[yellow]{colored}",
+			document: doc(par(
+				element("\\st", text("struck")),
+				text(" "),
+				element("\\ul", text("underlined")),
+				text(" "),
+				element("\\hl", text("marked")),
+				text(" "),
+				elementp("\\hl", map[string]string{"color": "yellow"}, text("colored")),
+			)),
+		},
+		{
+			name:   "internal references",
+			render: "\\label{sec:intro}See \\autoref{sec:intro}, \\nameref{sec:intro} or \\hyperref[sec:intro]{the \\textbf{intro}}.",
+			document: doc(par(
+				elementp("\\label", map[string]string{"label": "sec:intro"}),
+				text("See "),
+				elementp("\\autoref", map[string]string{"label": "sec:intro"}),
+				text(", "),
+				elementp("\\nameref", map[string]string{"label": "sec:intro"}),
+				text(" or "),
+				elementp("\\hyperref", map[string]string{"label": "sec:intro"}, text("the "), element("\\textbf", text("intro"))),
+				text("."),
+			)),
+		},
+		{
+			name:   "raisebox",
+			render: "x\\raisebox{-0.5ex}{y} z\\raisebox{2pt}[10pt][0pt]{up}",
+			document: doc(par(
+				text("x"),
+				elementp("\\raisebox", map[string]string{"lift": "-0.5ex"}, text("y")),
+				text(" z"),
+				elementp("\\raisebox", map[string]string{"lift": "2pt", "height": "10pt", "depth": "0pt"}, text("up")),
+			)),
+		},
+		{
+			name:   "examples",
+			render: "\\exmp{1 2\n}{3\n}\n\\exmpfile{example.01}{example.01.a}{first}",
+			document: doc(
+				elementp("\\exmp", map[string]string{"input": "1 2\n", "output": "3\n"}),
+				elementp("\\exmpfile", map[string]string{"input": "example.01", "output": "example.01.a", "name": "first"}),
+			),
+		},
+		{
+			name:     "empty math",
+			render:   "a $$ b $$$$",
+			document: doc(par(text("a "), element("$"), text(" b "), element("$$"))),
+		},
+		{
+			name:     "highlighted example line",
+			render:   "3 \\showln{1 2 3}",
+			document: doc(par(text("3 "), elementp("\\showln", map[string]string{"highlight": "true"}, text("1 2 3")))),
+		},
+		{
+			name:   "phantoms",
+			render: "a\\phantom{bc}d \\hphantom{$x$} \\vphantom{Tall}",
+			document: doc(par(
+				text("a"),
+				element("\\phantom", text("bc")),
+				text("d "),
+				element("\\hphantom", element("$", text("x"))),
+				text(" "),
+				element("\\vphantom", text("Tall")),
+			)),
+		},
 		{
 			name:   "nested spans",
 			render: "this is \\textbf{\\textit{bold and slanted}} but still good",
@@ -574,6 +869,23 @@ func TestRender(t *testing.T) {
 				text(" but still good"),
 			)),
 		},
+		{
+			name:   "custom environment with options",
+			render: "\\begin{grid}[columns=6]\n\n  This content is in the block.\n\n\n  $abacaba$\n\n\n\\end{grid}",
+			document: doc(elementp("grid",
+				map[string]string{"options": "columns=6"},
+				par(text("\n  This content is in the block.\n")),
+				par(text("  "), element("$", text("abacaba")), text("\n")),
+			)),
+		},
+		{
+			name:   "custom environment with unicode options",
+			render: "\\begin{admonition}[type=note, title=\"Привіт 👋\"]\nЯк справи? ⁉️\n\n\\end{admonition}",
+			document: doc(elementp("admonition",
+				map[string]string{"options": "type=note, title=\"Привіт 👋\""},
+				par(text("Як справи? ⁉️")),
+			)),
+		},
 	}
 
 	for _, tc := range tt {
@@ -602,3 +914,260 @@ func TestRender(t *testing.T) {
 		})
 	}
 }
+
+func TestRender_NestedEnvironments(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		want  []string // path to each element except paragraphs, in document order
+	}{
+		{
+			name:  "figure with centered table",
+			input: "\\begin{figure}\n\\begin{center}\n\\begin{tabular}{|c|c|}\n\\hline\na & \\textbf{b} \\\\\n\\hline\n\\end{tabular}\n\\end{center}\n\\caption{Table}\n\\end{figure}",
+			want: []string{
+				"figure",
+				"figure>center",
+				"figure>center>tabular",
+				"figure>center>tabular>\\hline",
+				"figure>center>tabular>\\row",
+				"figure>center>tabular>\\row>\\cell",
+				"figure>center>tabular>\\row>\\cell",
+				"figure>center>tabular>\\row>\\cell>\\textbf",
+				"figure>center>tabular>\\hline",
+				"figure>\\caption",
+			},
+		},
+		{
+			name:  "table with options",
+			input: "\\begin{table}[h]\n\\begin{center}\n\\begin{tabular}{c}\nx\n\\end{tabular}\n\\end{center}\n\\end{table}",
+			want: []string{
+				"table",
+				"table>center",
+				"table>center>tabular",
+				"table>center>tabular>\\row",
+				"table>center>tabular>\\row>\\cell",
+			},
+		},
+		{
+			name:  "table in minipage in list",
+			input: "\\begin{itemize}\n\\item \\begin{minipage}{0.5\\textwidth}\n\\begin{flushright}\n\\begin{tabular}{l}\na \\\\\n\\end{tabular}\n\\end{flushright}\n\\end{minipage}\n\\end{itemize}",
+			want: []string{
+				"itemize",
+				"itemize>\\item",
+				"itemize>\\item>minipage",
+				"itemize>\\item>minipage>flushright",
+				"itemize>\\item>minipage>flushright>tabular",
+				"itemize>\\item>minipage>flushright>tabular>\\row",
+				"itemize>\\item>minipage>flushright>tabular>\\row>\\cell",
+			},
+		},
+	}
+
+	var skeleton func(node *latex.Node, path string) []string
+	skeleton = func(node *latex.Node, path string) (paths []string) {
+		for _, child := range node.Children {
+			if child.Kind != latex.ElementKind {
+				continue
+			}
+
+			if child.Data == "\\par" {
+				paths = append(paths, skeleton(child, path)...)
+				continue
+			}
+
+			name := strings.TrimPrefix(path+">"+child.Data, ">")
+			paths = append(paths, name)
+			paths = append(paths, skeleton(child, name)...)
+		}
+
+		return
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := latex.NewStrictParser(strings.NewReader(tc.input)).Parse()
+			if err != nil {
+				t.Fatal("unable to parse:", err)
+			}
+
+			if got := skeleton(doc, ""); !cmp.Equal(tc.want, got) {
+				t.Fatalf("Parsed document does not match:\n%s", cmp.Diff(tc.want, got))
+			}
+
+			buffer := bytes.NewBuffer(nil)
+			if err := latex.Render(buffer, doc); err != nil {
+				t.Fatal("unable to render:", err)
+			}
+
+			rendered, err := latex.NewStrictParser(strings.NewReader(buffer.String())).Parse()
+			if err != nil {
+				t.Fatal("unable to parse rendered document:", err)
+			}
+
+			if got := skeleton(rendered, ""); !cmp.Equal(tc.want, got) {
+				t.Errorf("Rendered document does not match:\n%s\nRendered:\n%s", cmp.Diff(tc.want, got), buffer.String())
+			}
+		})
+	}
+}
+
+func TestRender_SpecialCharacters(t *testing.T) {
+	doc := &latex.Node{Kind: latex.DocumentKind, Children: []*latex.Node{
+		{Kind: latex.ElementKind, Data: "\\par", Children: []*latex.Node{{Kind: latex.TextKind, Data: "50% & a_b #1 $5 {x} — and/or"}}},
+	}}
+
+	want := "50\\% \\& a\\_b \\#1 \\$5 \\{x\\} --- and/or"
+
+	// replacement must not depend on map iteration order, so it's repeated a few times
+	for i := 0; i < 20; i++ {
+		b := &strings.Builder{}
+		if err := latex.Render(b, doc, latex.WithExactWhitespace()); err != nil {
+			t.Fatal("unable to render:", err)
+		}
+
+		if b.String() != want {
+			t.Fatalf("Render does not match:\nwant: %q\n got: %q", want, b.String())
+		}
+	}
+
+	// escaped text is parsed back to the same text
+	got, err := latex.NewStrictParser(strings.NewReader(want)).Parse()
+	if err != nil {
+		t.Fatal("unable to parse:", err)
+	}
+
+	if !cmp.Equal(doc, got) {
+		t.Errorf("Tree does not match:\n%s\n", cmp.Diff(doc, got))
+	}
+}
+
+func TestRender_RoundTrip(t *testing.T) {
+	tt := []string{
+		"# $ % ^ & _ { } ~ \\ [ ] ` ' - < >",
+		"#$%^&_{}~\\[]`'-<>",
+		"a -- b --- c '' d `` e << f >> g",
+		"----''''<<<>>>``",
+		"\\textbf{not a command} $x$ 100%",
+	}
+
+	for _, text := range tt {
+		t.Run(text, func(t *testing.T) {
+			doc := &latex.Node{Kind: latex.DocumentKind, Children: []*latex.Node{
+				{Kind: latex.ElementKind, Data: "\\par", Children: []*latex.Node{{Kind: latex.TextKind, Data: text}}},
+			}}
+
+			b := &strings.Builder{}
+			if err := latex.Render(b, doc, latex.WithExactWhitespace()); err != nil {
+				t.Fatal("unable to render:", err)
+			}
+
+			got, err := latex.NewStrictParser(strings.NewReader(b.String())).Parse()
+			if err != nil {
+				t.Fatalf("unable to parse %q: %v", b.String(), err)
+			}
+
+			if !cmp.Equal(doc, got) {
+				t.Errorf("Tree does not match after rendering to %q:\n%s\n", b.String(), cmp.Diff(doc, got))
+			}
+		})
+	}
+}
+
+func TestRender_Tables(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+	}{
+		{name: "multicolumn", input: "\\begin{tabular}{|c|c|c|}\n\\multicolumn{2}{|c|}{Title} & z \\\\\na & b & c\n\\end{tabular}"},
+		{name: "caption", input: "\\begin{tabular}{|c|c|}\n\\multicolumn{2}{|c|}{Results} \\\\ \\hline\nA & B\n\\end{tabular}"},
+		{name: "multirow", input: "\\begin{tabular}{cc}\n\\multirow{2}{*}{Name} & a \\\\\nb & c\n\\end{tabular}"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			want, err := latex.NewStrictParser(strings.NewReader(tc.input)).Parse()
+			if err != nil {
+				t.Fatal("unable to parse:", err)
+			}
+
+			b := &strings.Builder{}
+			if err := latex.Render(b, want, latex.WithExactWhitespace()); err != nil {
+				t.Fatal("unable to render:", err)
+			}
+
+			got, err := latex.NewStrictParser(strings.NewReader(b.String())).Parse()
+			if err != nil {
+				t.Fatalf("unable to parse %q: %v", b.String(), err)
+			}
+
+			if !cmp.Equal(want, got) {
+				t.Errorf("Tree does not match after rendering to %q:\n%s\n", b.String(), cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestRender_DollarMath(t *testing.T) {
+	input := "Let \\begin{math}x_i\\end{math} be\n\\begin{displaymath}x^2\\end{displaymath}"
+
+	doc, err := latex.NewStrictParser(strings.NewReader(input)).Parse()
+	if err != nil {
+		t.Fatal("unable to parse:", err)
+	}
+
+	b := &strings.Builder{}
+	if err := latex.Render(b, doc, latex.WithExactWhitespace(), latex.WithDollarMath()); err != nil {
+		t.Fatal("unable to render:", err)
+	}
+
+	if want := "Let $x_i$ be\n$$x^2$$"; b.String() != want {
+		t.Errorf("Render does not match:\nwant: %q\n got: %q", want, b.String())
+	}
+}
+
+// sourceTests are documents which are rendered back by Source exactly as written, they are also used as seed corpus
+// of FuzzParse
+var sourceTests = []struct {
+	name  string
+	input string
+}{
+	{name: "text", input: "Hello,  world!\nSecond line."},
+	{name: "paragraphs", input: "Hello,  world!\nSecond line.\n\nNew   paragraph with \\textbf{bold}  text."},
+	{name: "environment", input: "\\begin{center}\n  Centered  text\n\\end{center}\nAfter  text."},
+	{name: "list", input: "\\begin{itemize}\n\\item One\n\\item Two\n\\end{itemize}"},
+	{name: "math environment", input: "\\[ \\begin{pmatrix} 1 & 2 \\\\ 3 & 4 \\end{pmatrix} \\]"},
+	{name: "array environment", input: "Matrix \\begin{array}{cc} a & b \\\\ c & d \\end{array} here."},
+	{name: "no-op commands", input: "a\\relax b \\ignorespaces c"},
+	{name: "verbatim input", input: "See code:\n\\verbatiminput{main.cpp}"},
+	{name: "lstset", input: "\\lstset{language=C++,basicstyle={\\ttfamily}}Text"},
+	{name: "enumerate counters", input: "\\begin{enumerate}[resume]\n\\item a\n\\setcounter{enumi}{9}\\item b\n\\begin{enumerate}\n\\setcounter{enumii}{2}\\item c\n\\end{enumerate}\\end{enumerate}"},
+	{name: "equations", input: "\\begin{equation}\nx = 1 \\tag{A}\n\\end{equation}\n\\begin{align*}\na &= b \\\\\nc &= d\n\\end{align*}"},
+	{name: "math environments", input: "Let \\begin{math}x_i & y\\end{math} be\n\\begin{displaymath}\nx^2\n\\end{displaymath}"},
+	{name: "custom environment", input: "\\begin{grid}[columns=6]\n  This content is in the block.\n\n  $abacaba$\n\\end{grid}"},
+	{name: "custom environment with quoted options", input: "\\begin{admonition}[type=warning, title=\"Note: \\[50%\\]\"]Text\\end{admonition}"},
+	{name: "custom environment with unicode options", input: "\\begin{admonition}[type=note, title=\"Привіт 👋\"]Як справи? ⁉️\\end{admonition}"},
+	{name: "unknown starred environment", input: "\\begin{foo*}[x]\nText\n\\end{foo*}"},
+	{name: "table", input: "\\begin{tabular}{cc}\na & b \\\\\nc & d\n\\end{tabular}After"},
+	{name: "lstlisting", input: "\\begin{lstlisting}[language=C++]\nint a;\n\\end{lstlisting}"},
+	{name: "multicolumn", input: "\\begin{tabular}{|c|c|c|}\n\\multicolumn{2}{|c|}{Title} & z \\\\\na & b & c\n\\end{tabular}"},
+	{name: "leading line break", input: "\\begin{center}\\\\ text \\end{center}"},
+	{name: "table with repeated columns", input: "\\begin{tabular}{*{3}{c}}\na & b & c\n\\end{tabular}"},
+	{name: "packages", input: "\\documentclass{article}\\usepackage{amsmath}Text"},
+	{name: "bibliography", input: "\\begin{thebibliography}{9}\\bibitem{a} A\\end{thebibliography}"},
+	{name: "graphics and samples", input: "\\includegraphics{a.png}\\exmp{1}{2}\\exmp{3}{4}"},
+}
+
+func TestSource(t *testing.T) {
+	for _, tc := range sourceTests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := latex.NewStrictParser(strings.NewReader(tc.input)).Parse()
+			if err != nil {
+				t.Fatal("unable to parse:", err)
+			}
+
+			if got := latex.Source(doc); got != tc.input {
+				t.Errorf("Source does not match input:\nwant: %q\n got: %q", tc.input, got)
+			}
+		})
+	}
+}
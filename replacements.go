@@ -6,7 +6,19 @@ var specials = map[string]string{
 	"«":                    "<<",
 	"»":                    ">>",
 	string([]rune{0x00A0}): "~",
+	string([]rune{0x2009}): "\\,",
+	string([]rune{0x205F}): "\\:",
+	string([]rune{0x2005}): "\\;",
+	string([]rune{0x00AD}): "\\-",
 	"%":                    "\\%",
+	"&":                    "\\&",
+	"_":                    "\\_",
+	"#":                    "\\#",
+	"$":                    "\\$",
+	"~":                    "\\~{}",
+	"^":                    "\\^{}",
+	"\\":                   "\\textbackslash{}",
+	"`":                    "\\textasciigrave{}",
 	"{":                    "\\{",
 	"}":                    "\\}",
 	"[":                    "\\[",
@@ -16,6 +28,12 @@ var specials = map[string]string{
 var replacements = map[string]string{
 	"\\textwidth":            "",
 	"\\space":                " ",
+	"\\ ":                    " ",
+	"\\,":                    string([]rune{8201}),
+	"\\:":                    string([]rune{8287}),
+	"\\;":                    string([]rune{8197}),
+	"\\!":                    "",
+	"\\@":                    "",
 	"\\nobreakspace":         string([]rune{160}),
 	"\\thinspace":            string([]rune{8201}),
 	"\\enspace":              string([]rune{8194}),
@@ -0,0 +1,46 @@
+package latex
+
+// sectionMarkers is a list of section markers, each of them starts a new section and ends the previous one
+var sectionMarkers = map[string]bool{
+	"\\InputFile":   true,
+	"\\InputData":   true,
+	"\\OutputFile":  true,
+	"\\Note":        true,
+	"\\Scoring":     true,
+	"\\Interaction": true,
+	"\\Example":     true,
+	"\\Examples":    true,
+}
+
+// GroupSections returns a copy of the document where content following section markers (\\InputFile, \\OutputFile,
+// \\Note, \\Scoring etc.) is moved inside the marker element, up to the next section marker or the end of the
+// enclosing element.
+func GroupSections(doc *Node) *Node {
+	if len(doc.Children) == 0 {
+		return doc
+	}
+
+	grouped := *doc
+	grouped.Children = nil
+
+	var section *Node
+	for _, child := range doc.Children {
+		child = GroupSections(child)
+
+		if child.Kind == ElementKind && sectionMarkers[child.Data] {
+			marker := *child
+			section = &marker
+			grouped.Children = append(grouped.Children, section)
+			continue
+		}
+
+		if section != nil {
+			section.Children = append(section.Children, child)
+			continue
+		}
+
+		grouped.Children = append(grouped.Children, child)
+	}
+
+	return &grouped
+}
@@ -0,0 +1,49 @@
+package latex_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestGroupSections(t *testing.T) {
+	doc, err := latex.Parse(strings.NewReader("Statement.\n\n\\Scoring\n\nSubtask 1.\n\nSubtask 2.\n\n\\Note\n\nNote."))
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	got := latex.GroupSections(doc)
+	want := &latex.Node{Kind: latex.DocumentKind, Children: []*latex.Node{
+		{Kind: latex.ElementKind, Data: "\\par", Children: []*latex.Node{{Kind: latex.TextKind, Data: "Statement.\n"}}},
+		{Kind: latex.ElementKind, Data: "\\Scoring", Children: []*latex.Node{
+			{Kind: latex.ElementKind, Data: "\\par", Children: []*latex.Node{{Kind: latex.TextKind, Data: "Subtask 1.\n"}}},
+			{Kind: latex.ElementKind, Data: "\\par", Children: []*latex.Node{{Kind: latex.TextKind, Data: "Subtask 2.\n"}}},
+		}},
+		{Kind: latex.ElementKind, Data: "\\Note", Children: []*latex.Node{
+			{Kind: latex.ElementKind, Data: "\\par", Children: []*latex.Node{{Kind: latex.TextKind, Data: "Note."}}},
+		}},
+	}}
+
+	if !cmp.Equal(want, got) {
+		t.Errorf("Grouped document does not match:\n%s\n", cmp.Diff(want, got))
+	}
+
+	// original document is not modified
+	if len(doc.Children) != 6 {
+		t.Errorf("Original document is modified, it has %d children", len(doc.Children))
+	}
+
+	b := bytes.NewBuffer(nil)
+	if err := latex.RenderHTML(b, got); err != nil {
+		t.Fatal(err)
+	}
+
+	html := "<p>Statement.\n</p>\n<h3>Scoring</h3>\n<p>Subtask 1.\n</p>\n<p>Subtask 2.\n</p>\n<h3>Note</h3>\n<p>Note.</p>\n"
+	if b.String() != html {
+		t.Errorf("Rendered HTML does not match:\nwant: %q\n got: %q", html, b.String())
+	}
+}
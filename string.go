@@ -1,13 +1,73 @@
 package latex
 
+import (
+	"strings"
+	"unicode/utf8"
+)
+
 func String(node *Node) (out string) {
 	if node.Kind == TextKind {
 		return node.Data
 	}
 
+	// phantoms are invisible, but take as much space as their content
+	if node.Kind == ElementKind {
+		switch node.Data {
+		case "\\phantom", "\\hphantom":
+			return strings.Repeat(" ", utf8.RuneCountInString(String(&Node{Kind: ElementKind, Children: node.Children})))
+		case "\\vphantom":
+			return ""
+		case "\\slash":
+			return "/"
+		case "\\texorpdfstring":
+			// plain text alternative is preferred, it's what LaTeX uses in bookmarks
+			if plain, ok := node.Parameters["pdfstring"]; ok {
+				return plain
+			}
+		}
+	}
+
 	for _, child := range node.Children {
 		out += String(child)
 	}
 
 	return
 }
+
+// InnerText extracts text from the node like String does, but also includes text which is stored in parameters:
+// alternative text (or file name) of images, text and targets of links, and TeX source of math.
+func InnerText(node *Node) (out string) {
+	if node.Kind == TextKind {
+		return node.Data
+	}
+
+	if node.Kind == ElementKind {
+		switch node.Data {
+		case "$", "$$":
+			return node.Data + String(node) + node.Data
+		case "\\includegraphics", "\\includemedia":
+			if kv, err := KeyValue(node.Parameters["options"]); err == nil && kv["alt"] != "" {
+				return kv["alt"]
+			}
+
+			return node.Parameters["src"]
+		case "\\url":
+			return node.Parameters["href"]
+		case "\\href":
+			text := InnerText(&Node{Kind: ElementKind, Children: node.Children})
+			if href := node.Parameters["href"]; href != "" && href != text {
+				return text + " (" + href + ")"
+			}
+
+			return text
+		case "\\phantom", "\\hphantom", "\\vphantom", "\\texorpdfstring", "\\slash":
+			return String(node)
+		}
+	}
+
+	for _, child := range node.Children {
+		out += InnerText(child)
+	}
+
+	return
+}
@@ -0,0 +1,63 @@
+package latex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestString(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{name: "formatting", input: "odd \\textbf{foo \\textit{bar}} baz", output: "odd foo bar baz"},
+		{name: "phantoms", input: "a\\phantom{bc}d \\hphantom{xyz}|\\vphantom{Tall}|", output: "a  d    ||"},
+		{name: "slash", input: "input\\slash output", output: "input/output"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := latex.Parse(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			if got := latex.String(doc); got != tc.output {
+				t.Errorf("String does not match: want %#v, got %#v", tc.output, got)
+			}
+		})
+	}
+}
+
+func TestInnerText(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{name: "formatting", input: "odd \\textbf{foo \\textit{bar}} baz", output: "odd foo bar baz"},
+		{name: "phantoms", input: "a\\phantom{bc}d", output: "a  d"},
+		{name: "image file name", input: "see \\includegraphics[width=2cm]{tree.png}", output: "see tree.png"},
+		{name: "image alt text", input: "see \\includegraphics[width=2cm,alt={Binary tree}]{tree.png}", output: "see Binary tree"},
+		{name: "url", input: "visit \\url{https://eolymp.com}", output: "visit https://eolymp.com"},
+		{name: "href", input: "visit \\href{https://eolymp.com}{Eolymp}", output: "visit Eolymp (https://eolymp.com)"},
+		{name: "href with target as text", input: "\\href{https://eolymp.com}{https://eolymp.com}", output: "https://eolymp.com"},
+		{name: "math", input: "let $x^2$ be", output: "let $x^2$ be"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := latex.Parse(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			if got := latex.InnerText(doc); got != tc.output {
+				t.Errorf("InnerText does not match: want %#v, got %#v", tc.output, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package latex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Cell is a slot in the table grid returned by TableGrid
+type Cell struct {
+	Node    *Node // \\cell node, for covered slots it's the cell covering the slot
+	RowSpan int   // number of rows cell spans, at least 1
+	ColSpan int   // number of columns cell spans, at least 1
+	Covered bool  // slot is covered by a cell spanning multiple rows or columns
+	Header  bool  // whole content of the cell is bold, so it's likely a header cell
+	Top     bool  // horizontal rule (\\hline or \\cline) is drawn above the slot
+	Bottom  bool  // horizontal rule (\\hline or \\cline) is drawn below the slot
+}
+
+// TableGrid resolves rows and cells of tabular node into a rectangular grid: each row of the grid has a slot for
+// every column. Slots covered by cells spanning multiple columns (\\multicolumn) or rows (\\multirow) are marked as
+// covered. Rules (\\hline and \\cline) do not form rows of the grid, they are exposed as top and bottom borders of
+// the slots they are drawn between.
+//
+// LaTeX requires an empty placeholder cell in the columns covered by \\multirow from the rows above, these
+// placeholders are consumed and are not present in the grid. Rows shorter than the table are padded with empty
+// cells (with nil Node), so all rows of the grid have the same length.
+func TableGrid(table *Node) ([][]Cell, error) {
+	if table.Kind != ElementKind || (table.Data != "tabular" && table.Data != "tabular*" && table.Data != "tabularx") {
+		return nil, fmt.Errorf("table is expected, got %#v", table.Data)
+	}
+
+	var grid [][]Cell
+	var above []Cell  // cells covering each column from the rows above
+	var covered []int // number of rows below which are still covered by \\multirow cell, for each column
+
+	var rules [][]ruleSpan // rules above each row of the grid, the last item holds rules below the last row
+
+	width := 0
+	for _, row := range table.Children {
+		for len(rules) <= len(grid) {
+			rules = append(rules, nil)
+		}
+
+		if row.Kind == ElementKind && row.Data == "\\hline" {
+			rules[len(grid)] = append(rules[len(grid)], ruleSpan{from: 0, to: -1})
+			continue
+		}
+
+		if row.Kind == ElementKind && row.Data == "\\cline" {
+			rules[len(grid)] = append(rules[len(grid)], clineSpan(row.Parameters["range"]))
+			continue
+		}
+
+		if row.Kind != ElementKind || row.Data != "\\row" {
+			continue
+		}
+
+		var line []Cell
+		for _, node := range row.Children {
+			// column is covered by \\multirow from rows above, the cell is a placeholder
+			if len(line) < len(covered) && covered[len(line)] > 0 {
+				line = append(line, above[len(line)])
+				continue
+			}
+
+			column := len(line)
+			cell := Cell{Node: node, ColSpan: cellSpan(node.Parameters["colspan"]), RowSpan: cellSpan(node.Parameters["rowspan"]), Header: isBold(node)}
+
+			line = append(line, cell)
+			for i := 1; i < cell.ColSpan; i++ {
+				line = append(line, Cell{Node: node, ColSpan: cell.ColSpan, RowSpan: cell.RowSpan, Covered: true})
+			}
+
+			for len(covered) < len(line) {
+				covered = append(covered, 0)
+				above = append(above, Cell{})
+			}
+
+			for i := column; i < len(line); i++ {
+				covered[i] = cell.RowSpan
+				above[i] = Cell{Node: node, ColSpan: cell.ColSpan, RowSpan: cell.RowSpan, Covered: true}
+			}
+		}
+
+		// placeholders at the end of the row might be omitted
+		for len(line) < len(covered) && covered[len(line)] > 0 {
+			line = append(line, above[len(line)])
+		}
+
+		for i := range covered {
+			if covered[i] > 0 {
+				covered[i]--
+			}
+		}
+
+		if len(line) > width {
+			width = len(line)
+		}
+
+		grid = append(grid, line)
+	}
+
+	for i := range grid {
+		for len(grid[i]) < width {
+			grid[i] = append(grid[i], Cell{RowSpan: 1, ColSpan: 1})
+		}
+	}
+
+	for i, spans := range rules {
+		for _, span := range spans {
+			to := span.to
+			if to < 0 || to >= width {
+				to = width - 1
+			}
+
+			for column := span.from; column <= to; column++ {
+				if i < len(grid) {
+					grid[i][column].Top = true
+				}
+
+				if i > 0 {
+					grid[i-1][column].Bottom = true
+				}
+			}
+		}
+	}
+
+	return grid, nil
+}
+
+// ruleSpan is a range of columns (zero-based, inclusive) horizontal rule is drawn across, negative end means the rule
+// goes to the last column
+type ruleSpan struct {
+	from, to int
+}
+
+// clineSpan parses range of \\cline, like "2-3" (one-based), into a span of columns
+func clineSpan(value string) ruleSpan {
+	from, to, found := strings.Cut(value, "-")
+	if !found {
+		to = from
+	}
+
+	a, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil || a < 1 {
+		a = 1
+	}
+
+	b, err := strconv.Atoi(strings.TrimSpace(to))
+	if err != nil || b < a {
+		b = a
+	}
+
+	return ruleSpan{from: a - 1, to: b - 1}
+}
+
+// cellSpan parses number of rows or columns cell spans, it's at least 1
+func cellSpan(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 1
+	}
+
+	return n
+}
+
+// isBold checks if whole content of the node is wrapped in \\bf, \\textbf or \\bfseries, whitespaces are ignored
+func isBold(node *Node) bool {
+	bold, plain := boldContent(node)
+	return bold && !plain
+}
+
+// boldContent reports if node contains bold content and if it contains content which is not bold. Declaration form
+// (\\bfseries without argument) makes the rest of the node bold.
+func boldContent(node *Node) (bold, plain bool) {
+	declared := false
+
+	for _, child := range node.Children {
+		switch {
+		case declared:
+			bold = true
+		case child.Kind == TextKind:
+			plain = plain || strings.TrimSpace(child.Data) != ""
+		case child.Data == "\\bf" || child.Data == "\\textbf" || child.Data == "\\bfseries":
+			bold = true
+			declared = len(child.Children) == 0
+		case child.Data == "\\par":
+			b, p := boldContent(child)
+			bold, plain = bold || b, plain || p
+		default:
+			plain = true
+		}
+	}
+
+	return
+}
@@ -0,0 +1,161 @@
+package latex_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestTableGrid(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		grid  [][]string // text of each cell with its span, "-" for covered slots and "" for padding
+	}{
+		{
+			name:  "simple table",
+			input: "\\begin{tabular}{ll}a & b \\\\ \\hline c & d\\end{tabular}",
+			grid:  [][]string{{"a", "b"}, {"c", "d"}},
+		},
+		{
+			name:  "multicolumn and multirow",
+			input: "\\begin{tabular}{|c|c|c|c|c|}\n\\hline\n\\multirow{2}{*}{Group} & \\multicolumn{2}{c|}{Constraints} & \\multirow{2}{*}{Points} & \\multirow{2}{*}{Groups} \\\\ \\cline{2-3}\n & $n$ & $a_i$ & & \\\\ \\hline\n1 & 10 & --- & 12 & --- \\\\ \\hline\\end{tabular}",
+			grid: [][]string{
+				{"Group 2x1", "Constraints 1x2", "-", "Points 2x1", "Groups 2x1"},
+				{"-", "n", "a_i", "-", "-"},
+				{"1", "10", "—", "12", "—"},
+			},
+		},
+		{
+			name:  "omitted trailing placeholders",
+			input: "\\begin{tabular}{ll}a & \\multirow{2}{*}{b} \\\\ c\\end{tabular}",
+			grid:  [][]string{{"a", "b 2x1"}, {"c", "-"}},
+		},
+		{
+			name:  "short rows",
+			input: "\\begin{tabular}{lll}a \\\\ b & c & d\\end{tabular}",
+			grid:  [][]string{{"a", "", ""}, {"b", "c", "d"}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := latex.Parse(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			grid, err := latex.TableGrid(doc.Children[0])
+			if err != nil {
+				t.Fatalf("Unable to build table grid: %v", err)
+			}
+
+			var got [][]string
+			for _, row := range grid {
+				var line []string
+				for _, cell := range row {
+					switch {
+					case cell.Covered:
+						line = append(line, "-")
+					case cell.Node == nil:
+						line = append(line, "")
+					case cell.RowSpan > 1 || cell.ColSpan > 1:
+						line = append(line, fmt.Sprintf("%s %dx%d", strings.TrimSpace(latex.String(cell.Node)), cell.RowSpan, cell.ColSpan))
+					default:
+						line = append(line, strings.TrimSpace(latex.String(cell.Node)))
+					}
+				}
+
+				got = append(got, line)
+			}
+
+			if !cmp.Equal(tc.grid, got) {
+				t.Errorf("Grid does not match:\n%s", cmp.Diff(tc.grid, got))
+			}
+		})
+	}
+}
+
+func TestTableGrid_NotTable(t *testing.T) {
+	if _, err := latex.TableGrid(&latex.Node{Kind: latex.ElementKind, Data: "center"}); err == nil {
+		t.Error("TableGrid must return an error for non-table node")
+	}
+}
+
+func TestTableGrid_Header(t *testing.T) {
+	input := "\\begin{tabular}{lll}\n\\bf{Group} & \\textbf{Points} & \\textbf{Limits} \\\\ \\hline\n1 & \\textbf{10} & $n \\le 10$ \\\\\n2 & \\textbf{90} and more & --- \\end{tabular}"
+
+	doc, err := latex.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	grid, err := latex.TableGrid(doc.Children[0])
+	if err != nil {
+		t.Fatalf("Unable to build table grid: %v", err)
+	}
+
+	want := [][]bool{{true, true, true}, {false, true, false}, {false, false, false}}
+
+	var got [][]bool
+	for _, row := range grid {
+		var line []bool
+		for _, cell := range row {
+			line = append(line, cell.Header)
+		}
+
+		got = append(got, line)
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Errorf("Header cells do not match:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestTableGrid_Rules(t *testing.T) {
+	input := "\\begin{tabular}{|c|c|c|c|}\n\\hline\n\\multirow{2}{*}{Group} & \\multicolumn{2}{c|}{Constraints} & Points \\\\ \\cline{2-3}\n & $n$ & $a_i$ & \\\\ \\hline\n1 & 10 & --- & 12 \\\\ \\hline\\end{tabular}"
+
+	doc, err := latex.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	grid, err := latex.TableGrid(doc.Children[0])
+	if err != nil {
+		t.Fatalf("Unable to build table grid: %v", err)
+	}
+
+	// borders of each slot: "t" for top and "b" for bottom rule
+	want := [][]string{
+		{"t", "tb", "tb", "t"},
+		{"b", "tb", "tb", "b"},
+		{"tb", "tb", "tb", "tb"},
+	}
+
+	var got [][]string
+	for _, row := range grid {
+		var line []string
+		for _, cell := range row {
+			border := ""
+			if cell.Top {
+				border += "t"
+			}
+
+			if cell.Bottom {
+				border += "b"
+			}
+
+			line = append(line, border)
+		}
+
+		got = append(got, line)
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Errorf("Rules do not match:\n%s", cmp.Diff(want, got))
+	}
+}
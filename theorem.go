@@ -0,0 +1,36 @@
+package latex
+
+import (
+	"strconv"
+)
+
+// theoremEnvironments is a list of theorem-like environments (amsthm), numbered ones share numbering within the same
+// environment name
+var theoremEnvironments = map[string]bool{
+	"theorem":    true,
+	"lemma":      true,
+	"definition": true,
+	"corollary":  true,
+	"remark":     true,
+	"proof":      false,
+}
+
+// NumberTheorems assigns "number" parameter to each theorem-like environment (theorem, lemma, definition etc.)
+// according to their order in the document. Each environment is numbered independently, proofs are not numbered.
+func NumberTheorems(doc *Node) {
+	counters := map[string]int{}
+
+	walk(doc, func(node *Node) {
+		if node.Kind != ElementKind || !theoremEnvironments[node.Data] {
+			return
+		}
+
+		counters[node.Data]++
+
+		if node.Parameters == nil {
+			node.Parameters = map[string]string{}
+		}
+
+		node.Parameters["number"] = strconv.Itoa(counters[node.Data])
+	})
+}
@@ -0,0 +1,42 @@
+package latex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestNumberTheorems(t *testing.T) {
+	input := "\\begin{theorem}[Fermat]\nFirst.\n\\end{theorem}\n" +
+		"\\begin{lemma}\nSecond.\n\\end{lemma}\n" +
+		"\\begin{proof}\nThird.\n\\end{proof}\n" +
+		"\\begin{theorem}\nFourth.\n\\end{theorem}\n"
+
+	doc, err := latex.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	latex.NumberTheorems(doc)
+
+	var got []map[string]string
+	for _, node := range doc.Children {
+		if node.Kind == latex.ElementKind && node.Data != "\\par" {
+			got = append(got, node.Parameters)
+		}
+	}
+
+	want := []map[string]string{
+		{"name": "Fermat", "number": "1"},
+		{"number": "1"},
+		nil,
+		{"number": "2"},
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Errorf("Theorem numbers do not match:\n%s\n", cmp.Diff(want, got))
+	}
+}
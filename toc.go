@@ -0,0 +1,100 @@
+package latex
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TOCEntry is an entry in the table of contents
+type TOCEntry struct {
+	Level  int    // sectioning level: 0 for chapter, 1 for section, 2 for subsection etc.
+	Title  string // entry title
+	Anchor string // anchor entry is linked to, empty if there is none
+}
+
+var sectioningLevels = map[string]int{
+	"chapter":       0,
+	"section":       1,
+	"subsection":    2,
+	"subsubsection": 3,
+	"paragraph":     4,
+	"subparagraph":  5,
+}
+
+// TableOfContents collects table of contents entries from sectioning commands and \\addcontentsline commands.
+// If \\addcontentsline is preceded by \\phantomsection, entry is linked to the phantom section anchor.
+func TableOfContents(node *Node) (toc []TOCEntry) {
+	anchor := ""
+
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		if node.Kind == ElementKind {
+			switch node.Data {
+			case "\\phantomsection":
+				anchor = node.Parameters["anchor"]
+				return
+			case "\\addcontentsline":
+				level, ok := sectioningLevels[node.Parameters["level"]]
+				if node.Parameters["file"] == "toc" && ok {
+					toc = append(toc, TOCEntry{Level: level, Title: String(node), Anchor: anchor})
+				}
+
+				anchor = ""
+				return
+			case "\\chapter", "\\section", "\\subsection", "\\subsubsection":
+				toc = append(toc, TOCEntry{Level: sectioningLevels[node.Data[1:]], Title: String(node)})
+				anchor = ""
+				return
+			}
+		}
+
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+
+	walk(node)
+
+	return
+}
+
+// NumberSections assigns "number" parameter (like "2.3.1") to each \\section, \\subsection and \\subsubsection
+// according to their order in the document. Numbering of nested levels restarts with each new parent section.
+// After \\appendix sections are numbered with letters (A, B, C etc.).
+func NumberSections(doc *Node) {
+	counters := make([]int, 3)
+	style := "\\arabic*"
+
+	walk(doc, func(node *Node) {
+		if node.Kind != ElementKind {
+			return
+		}
+
+		if node.Data == "\\appendix" {
+			style = "\\Alph*"
+			counters[0] = 0
+			return
+		}
+
+		level, ok := sectioningLevels[strings.TrimPrefix(node.Data, "\\")]
+		if !ok || level < 1 || level > len(counters) {
+			return
+		}
+
+		counters[level-1]++
+		for i := level; i < len(counters); i++ {
+			counters[i] = 0
+		}
+
+		number := []string{formatCounter(counters[0], style)}
+		for i := 1; i < level; i++ {
+			number = append(number, strconv.Itoa(counters[i]))
+		}
+
+		if node.Parameters == nil {
+			node.Parameters = map[string]string{}
+		}
+
+		node.Parameters["number"] = strings.Join(number, ".")
+	})
+}
@@ -0,0 +1,95 @@
+package latex_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestTableOfContents(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		output []latex.TOCEntry
+	}{
+		{
+			name:  "sections",
+			input: "\\section{Intro}\nfoo\n\\subsection{Details}\nbar",
+			output: []latex.TOCEntry{
+				{Level: 1, Title: "Intro"},
+				{Level: 2, Title: "Details"},
+			},
+		},
+		{
+			name:  "plain text alternative",
+			input: "\\section{Complexity \\texorpdfstring{$O(n^2)$}{O(n2)}}",
+			output: []latex.TOCEntry{
+				{Level: 1, Title: "Complexity O(n2)"},
+			},
+		},
+		{
+			name:  "phantom section",
+			input: "\\section{Intro}\n\n\\phantomsection\n\\addcontentsline{toc}{section}{Acknowledgements}\nThanks!",
+			output: []latex.TOCEntry{
+				{Level: 1, Title: "Intro"},
+				{Level: 1, Title: "Acknowledgements", Anchor: "phantomsection-1"},
+			},
+		},
+		{
+			name:  "contents line without anchor",
+			input: "\\addcontentsline{toc}{subsection}{Appendix}\\addcontentsline{lof}{figure}{Figure}",
+			output: []latex.TOCEntry{
+				{Level: 2, Title: "Appendix"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := latex.Parse(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("Unable to parse document: %v", err)
+			}
+
+			got := latex.TableOfContents(doc)
+			want := tc.output
+
+			if !cmp.Equal(want, got) {
+				t.Errorf("Table of contents does not match:\n%s\n", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestNumberSections(t *testing.T) {
+	input := "\\section{Intro}\\subsection{Goals}\\subsection{Scope}\\subsubsection{Details}\\section{Method}\\subsection{Data}\\appendix\\section{Proofs}\\subsection{Lemma}\\section{Tables}"
+
+	doc, err := latex.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unable to parse document: %v", err)
+	}
+
+	latex.NumberSections(doc)
+
+	var got []string
+	var walk func(node *latex.Node)
+	walk = func(node *latex.Node) {
+		if number, ok := node.Parameters["number"]; ok {
+			got = append(got, number+" "+latex.String(node))
+		}
+
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+
+	walk(doc)
+
+	want := []string{"1 Intro", "1.1 Goals", "1.2 Scope", "1.2.1 Details", "2 Method", "2.1 Data", "A Proofs", "A.1 Lemma", "B Tables"}
+	if !cmp.Equal(want, got) {
+		t.Errorf("Section numbers do not match:\n%s\n", cmp.Diff(want, got))
+	}
+}
@@ -14,7 +14,10 @@ type Scanner interface {
 }
 
 type Tokenizer struct {
-	r Scanner
+	r       Scanner
+	start   int64  // position where the last token starts, used to unread it
+	lenient bool   // math not closed at the end of input is treated as closed
+	space   string // whitespace skipped after the last token if it's a line break, it's kept to render source back
 }
 
 func NewTokenizer(r Scanner) *Tokenizer {
@@ -22,7 +25,9 @@ func NewTokenizer(r Scanner) *Tokenizer {
 }
 
 func (l *Tokenizer) Token() (any, error) {
-	char, _, err := l.r.ReadRune()
+	l.space = ""
+
+	char, size, err := l.r.ReadRune()
 	if err != nil {
 		return nil, err
 	}
@@ -32,6 +37,8 @@ func (l *Tokenizer) Token() (any, error) {
 		return nil, err
 	}
 
+	l.start = pos - int64(size)
+
 	var token any
 
 	switch char {
@@ -60,7 +67,7 @@ func (l *Tokenizer) Token() (any, error) {
 		}
 
 		// go back one symbol as it's part of the text
-		if _, err := l.r.Seek(pos-int64(len(string(char))), io.SeekStart); err != nil {
+		if _, err := l.r.Seek(pos-int64(size), io.SeekStart); err != nil {
 			return nil, err
 		}
 
@@ -78,6 +85,12 @@ func (l *Tokenizer) Token() (any, error) {
 	return token, nil
 }
 
+// Unread moves back to the beginning of the last token returned by Token, so it's returned again by the next call
+func (l *Tokenizer) Unread() error {
+	_, err := l.r.Seek(l.start, io.SeekStart)
+	return err
+}
+
 // Verbatim reads render rune by rune until stop returns true
 func (l *Tokenizer) Verbatim(stop func(rune, error) bool) (string, error) {
 	var runes []rune
@@ -95,6 +108,21 @@ func (l *Tokenizer) Verbatim(stop func(rune, error) bool) (string, error) {
 	}
 }
 
+// Start returns position where the last token returned by Token starts, in bytes
+func (l *Tokenizer) Start() int64 {
+	return l.start
+}
+
+// Offset returns current position in the input, in bytes
+func (l *Tokenizer) Offset() int64 {
+	pos, err := l.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+
+	return pos
+}
+
 func (l *Tokenizer) Peek() (rune, error) {
 	read, _, err := l.r.ReadRune()
 	if err != nil {
@@ -152,6 +180,15 @@ func (l *Tokenizer) readMath() (any, error) {
 	for {
 		read, _, err := l.r.ReadRune()
 		if err == io.EOF {
+			// in lenient mode math is closed at the end of input
+			if l.lenient {
+				if !isBlock {
+					return Verbatim{Kind: "$", Data: string(runes[1:])}, nil
+				}
+
+				return Verbatim{Kind: "$$", Data: string(runes[2:])}, nil
+			}
+
 			// the block is not closed, let's recover from this error by returning opening sequence as text
 			if _, err := l.r.Seek(start, io.SeekStart); err != nil {
 				return nil, err
@@ -205,11 +242,25 @@ func (l *Tokenizer) readBackslash() (any, error) {
 			return nil, err
 		}
 
+		command := Command([]rune{'\\', r})
 		if star {
-			return Command([]rune{'\\', r, '*'}), l.Skip()
+			command = Command([]rune{'\\', r, '*'})
 		}
 
-		return Command([]rune{'\\', r}), l.Skip()
+		l.space, err = l.whitespace()
+
+		return command, err
+	}
+
+	// discretionary hyphen, unlike "\\\\" it doesn't have starred form and keeps whitespaces after it, dashes
+	// following it are read as ligatures: "\\---" is a discretionary hyphen and an en-dash
+	if r == '-' {
+		return Command("\\-"), nil
+	}
+
+	// spacing commands, unlike other commands they don't skip whitespaces after them
+	if isSpacing(r) {
+		return Command([]rune{'\\', r}), nil
 	}
 
 	// a letter means it's a named command \xyz
@@ -302,9 +353,18 @@ func (l *Tokenizer) readBlockStart() (any, error) {
 		return nil, err
 	}
 
+	// error: environment name is expected, but we can recover from it, empty name in \\begin{} is reported by parser
 	if word == "" {
-		// error: environment name is expected, but we can recover from it
-		return Text("\\begin{"), nil
+		if r, err := l.Peek(); err != nil || r != '}' {
+			return Text("\\begin{"), nil
+		}
+	}
+
+	// starred environments, like tabular*
+	if star, err := l.star(); err != nil {
+		return nil, err
+	} else if star && word != "" {
+		word += "*"
 	}
 
 	if err := l.expect('}'); err != nil {
@@ -325,7 +385,16 @@ func (l *Tokenizer) readBlockEnd() (any, error) {
 	}
 
 	if word == "" {
-		return nil, errors.New("environment name is expected")
+		if r, err := l.Peek(); err != nil || r != '}' {
+			return nil, errors.New("environment name is expected")
+		}
+	}
+
+	// starred environments, like tabular*
+	if star, err := l.star(); err != nil {
+		return nil, err
+	} else if star && word != "" {
+		word += "*"
 	}
 
 	if err := l.expect('}'); err != nil {
@@ -504,20 +573,41 @@ func (l *Tokenizer) readVerbatimBlock(kind string) (any, error) {
 
 		runes = append(runes, read)
 
-		// todo: what if end is escaped: \\\\end{kind}?
-		if strings.HasSuffix(string(runes), "\\end{"+kind+"}") {
+		if isVerbatimEnd(string(runes), kind) {
 			return Verbatim{Kind: kind, Data: strings.TrimSuffix(string(runes), "\\end{"+kind+"}")}, nil
 		}
 	}
 }
 
+// isVerbatimEnd checks if content of verbatim block ends with \\end{kind} which closes the block. In comments
+// "\\\\end{comment}" is a line break followed by text, so escaped \\end does not close them. Other blocks (like verbatim)
+// end at the first \\end{kind} as in LaTeX, even if it's preceded by a backslash.
+func isVerbatimEnd(content, kind string) bool {
+	end := "\\end{" + kind + "}"
+	if !strings.HasSuffix(content, end) {
+		return false
+	}
+
+	if kind != "comment" {
+		return true
+	}
+
+	slashes := 0
+	for i := len(content) - len(end) - 1; i >= 0 && content[i] == '\\'; i-- {
+		slashes++
+	}
+
+	return slashes%2 == 0
+}
+
 func (l *Tokenizer) readVerbatim(command string) (any, error) {
 	delimiter, _, err := l.r.ReadRune()
 	if err != nil {
 		return nil, err
 	}
 
-	if isWhitespace(delimiter) || isLetter(delimiter) || delimiter == '*' {
+	// same as LaTeX, whitespaces, letters, star and opening brace are not accepted as delimiters
+	if isWhitespace(delimiter) || isLetter(delimiter) || delimiter == '*' || delimiter == '{' {
 		return nil, fmt.Errorf("delimiter character \"%c\" is not allowed", delimiter)
 	}
 
@@ -555,6 +645,27 @@ func (l *Tokenizer) Skip() error {
 	}
 }
 
+// whitespace reads whitespaces until next non-whitespace symbol and returns them
+func (l *Tokenizer) whitespace() (string, error) {
+	var runes []rune
+	for {
+		r, _, err := l.r.ReadRune()
+		if err == io.EOF {
+			return string(runes), nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if !isWhitespace(r) {
+			return string(runes), l.r.UnreadRune()
+		}
+
+		runes = append(runes, r)
+	}
+}
+
 // Skip until next non-whitespace symbol or end of line
 func (l *Tokenizer) SkipEOL() error {
 	for {
@@ -676,10 +787,21 @@ func isWhitespace(r rune) bool {
 	}
 }
 
+// isSpacing checks if rune forms spacing command: control space "\\ ", thin spaces "\\,", "\\:", "\\;", negative thin
+// space "\\!" and "\\@" which changes spacing after period
+func isSpacing(r rune) bool {
+	switch r {
+	case ' ', ',', ':', ';', '!', '@':
+		return true
+	default:
+		return false
+	}
+}
+
 // isCommand checks if symbol represents "one-symbol" command
 func isCommand(r rune) bool {
 	switch r {
-	case '\\', '-':
+	case '\\':
 		return true
 	default:
 		return false
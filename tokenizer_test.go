@@ -25,6 +25,24 @@ func TestLexer(t *testing.T) {
 				latex.Text("three"),
 			},
 		},
+		{
+			name:  "environment without name",
+			input: "\\begin{}a\\end{}",
+			output: []any{
+				latex.EnvironmentStart{},
+				latex.Text("a"),
+				latex.EnvironmentEnd{},
+			},
+		},
+		{
+			name:  "starred environment",
+			input: "\\begin{tabular*}a\\end{tabular*}",
+			output: []any{
+				latex.EnvironmentStart{Name: "tabular*"},
+				latex.Text("a"),
+				latex.EnvironmentEnd{Name: "tabular*"},
+			},
+		},
 		{
 			name:  "command",
 			input: "\\textbf{foo\\par bar}",
@@ -150,6 +168,32 @@ func TestLexer(t *testing.T) {
 				latex.Command("\\ldots"),
 			},
 		},
+		{
+			name:  "verb command with brace delimiter",
+			input: "\\verb{x}",
+			output: []any{
+				latex.Text("\\verb"),
+				latex.ParameterStart{},
+				latex.Text("x"),
+				latex.ParameterEnd{},
+			},
+		},
+		{
+			name:  "verb command with letter delimiter",
+			input: "\\verb axa",
+			output: []any{
+				latex.Text("\\verb"),
+				latex.Text(" axa"),
+			},
+		},
+		{
+			name:  "verb command with star delimiter",
+			input: "\\verb**x*",
+			output: []any{
+				latex.Text("\\verb*"),
+				latex.Text("*x*"),
+			},
+		},
 		{
 			name:  "verbatim environment",
 			input: "\\begin{verbatim}\n10 PRINT \"HELLO WORLD \";\n20 GOTO 10\n\\end{verbatim}",
@@ -386,6 +430,20 @@ func TestLexer(t *testing.T) {
 				latex.Text(" other text"),
 			},
 		},
+		{
+			name:  "discretionary hyphen",
+			input: "hy\\-phen \\- x\\---y",
+			output: []any{
+				latex.Text("hy"),
+				latex.Command("\\-"),
+				latex.Text("phen "),
+				latex.Command("\\-"),
+				latex.Text(" x"),
+				latex.Command("\\-"),
+				latex.Symbol("--"),
+				latex.Text("y"),
+			},
+		},
 		{
 			name:  "unclosed block",
 			input: "one\\begin}...two",
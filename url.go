@@ -0,0 +1,119 @@
+package latex
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// URLSanitizer validates URL used as a target of a link or a source of an image in HTML output, it returns URL to be
+// used (possibly modified) and false if URL must be dropped
+type URLSanitizer func(url string) (string, bool)
+
+// DefaultURLSanitizer allows http, https and mailto URLs, relative URLs and data URIs of raster images. Other URLs,
+// including javascript: and other data: URIs, are rejected. Tabs, line breaks and leading or trailing control
+// characters are removed from the URL the same way browsers do before the scheme is checked.
+func DefaultURLSanitizer(url string) (string, bool) {
+	url = cleanURL(url)
+
+	switch urlScheme(url) {
+	case "", "http", "https", "mailto":
+		return url, true
+	case "data":
+		// media type is followed by parameters (;base64) or data (,)
+		media, _, _ := strings.Cut(strings.ToLower(url), ",")
+		media, _, _ = strings.Cut(strings.TrimPrefix(media, "data:"), ";")
+
+		switch media {
+		case "image/png", "image/jpeg", "image/gif", "image/webp":
+			return url, true
+		default:
+			return "", false
+		}
+	default:
+		return "", false
+	}
+}
+
+// cleanURL removes tabs and line breaks and trims leading and trailing control characters and spaces, as WHATWG URL
+// parser does, so that "java\tscript:" is not mistaken for a relative URL
+func cleanURL(url string) string {
+	url = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+
+		return r
+	}, url)
+
+	return strings.TrimFunc(url, func(r rune) bool {
+		return r <= ' '
+	})
+}
+
+// escapeWhitespace percent-encodes whitespace and control characters, they are not allowed in URLs, but spaces are
+// common in file names. Leading and trailing spaces are removed as browsers ignore them.
+func escapeWhitespace(url string) string {
+	url = strings.TrimFunc(url, func(r rune) bool {
+		return r <= ' '
+	})
+
+	b := strings.Builder{}
+	for _, r := range url {
+		if !unicode.IsSpace(r) && !unicode.IsControl(r) {
+			b.WriteRune(r)
+			continue
+		}
+
+		for _, c := range []byte(string(r)) {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+// urlScheme returns scheme of the URL in lower case, it returns empty string for relative URLs
+func urlScheme(url string) string {
+	scheme, _, found := strings.Cut(cleanURL(url), ":")
+	if !found || !isScheme(scheme) {
+		return ""
+	}
+
+	return strings.ToLower(scheme)
+}
+
+// normalizeURL adds https:// prefix to scheme-relative URLs (//eolymp.com) and bare domains (eolymp.com/problems)
+func normalizeURL(url string) string {
+	url = strings.TrimSpace(url)
+
+	switch {
+	case strings.HasPrefix(url, "//"):
+		return "https:" + url
+	case urlScheme(url) != "":
+		return url
+	case strings.HasPrefix(url, "/") || strings.HasPrefix(url, "#") || strings.HasPrefix(url, "?") || strings.HasPrefix(url, "."):
+		return url
+	}
+
+	if host, _, _ := strings.Cut(url, "/"); strings.Contains(host, ".") {
+		return "https://" + url
+	}
+
+	return url
+}
+
+// isScheme checks if value is a valid URL scheme: a letter followed by letters, digits, "+", "-" or "."
+func isScheme(value string) bool {
+	if value == "" || !isLetter(rune(value[0])) {
+		return false
+	}
+
+	for _, char := range value {
+		if !isLetter(char) && !isDigit(char, 10) && char != '+' && char != '-' && char != '.' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,40 @@
+package latex_test
+
+import (
+	"testing"
+
+	"github.com/eolymp/go-latex"
+)
+
+func TestDefaultURLSanitizer(t *testing.T) {
+	tt := []struct {
+		url     string
+		allowed bool
+	}{
+		{url: "https://eolymp.com/problems", allowed: true},
+		{url: "HTTP://eolymp.com", allowed: true},
+		{url: "mailto:support@eolymp.com", allowed: true},
+		{url: "//eolymp.com", allowed: true},
+		{url: "/problems/1", allowed: true},
+		{url: "#section", allowed: true},
+		{url: "images/eolymp.png", allowed: true},
+		{url: "data:image/jpeg;base64,/9j/4AAQ", allowed: true},
+		{url: "javascript:alert(1)", allowed: false},
+		{url: " JavaScript:alert(1)", allowed: false},
+		{url: "java\tscript:alert(1)", allowed: false},
+		{url: "java\nscript:alert(2)", allowed: false},
+		{url: "\x01javascript:alert(3)", allowed: false},
+		{url: "vbscript:msgbox(1)", allowed: false},
+		{url: "data:text/html,<script>alert(1)</script>", allowed: false},
+		{url: "data:image/svg+xml;base64,PHN2Zz4=", allowed: false},
+		{url: "ftp://eolymp.com/file", allowed: false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.url, func(t *testing.T) {
+			if _, ok := latex.DefaultURLSanitizer(tc.url); ok != tc.allowed {
+				t.Errorf("URL %#v is expected to be allowed: %v, got %v", tc.url, tc.allowed, ok)
+			}
+		})
+	}
+}
@@ -16,5 +16,5 @@ func stringify(children []*Node) (str string, err error) {
 }
 
 func isNewline(name string) bool {
-	return name == "\\\\" || name == "\\newline" || name == "\\*"
+	return name == "\\\\" || name == "\\\\*" || name == "\\newline"
 }